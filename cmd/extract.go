@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	timeline "github.com/acaird/timeline/pkg/timeline"
+
+	"github.com/llgcode/draw2d/draw2dimg"
+	"github.com/yuseferi/zax"
+)
+
+// failuresFileName is the file in the output directory that lists the
+// timelines that couldn't be parsed, and why
+const failuresFileName = "failures.txt"
+
+// extract is the "timeline extract" command, which writes every
+// timeline in a MediaWiki XML dump to a .data file named after its
+// page, and draws them too if -render is given
+func extract(ctx context.Context, arguments []string) {
+	sugar := zax.Get(ctx).Sugar()
+
+	fs := flag.NewFlagSet("extract", flag.ExitOnError)
+	outputDir := fs.String("o", ".", "directory to write the .data (and .png) files to")
+	render := fs.Bool("render", false, "draw each timeline into a .png file next to its .data file")
+	drawing := addDrawFlags(fs)
+	mode := fs.String("mode", "normal", modeUsage)
+	now := fs.String("now", "", "the time to use for \"now\" in the timelines, like 2025-03-01 or 2025-03-01T12:00:00Z\n(default: $SOURCE_DATE_EPOCH if it is set, otherwise the current time)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: timeline extract [options] pages-articles.xml[.bz2]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(arguments)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	clock, err := getClock(*now)
+	if err != nil {
+		sugar.Fatalf("%s", err.Error())
+	}
+	parseMode, err := getMode(*mode)
+	if err != nil {
+		sugar.Fatalf("%s", err.Error())
+	}
+	if err := os.MkdirAll(*outputDir, 0o755); err != nil {
+		sugar.Fatalf("couldn't make the output directory: %s", err.Error())
+	}
+	dump, err := os.Open(fs.Arg(0))
+	if err != nil {
+		sugar.Fatalf("%s", err.Error())
+	}
+	defer dump.Close()
+
+	var failures strings.Builder
+	var found, failed int
+	used := fileNames{}
+	err = timeline.ReadDump(dump, func(page timeline.Page) error {
+		// most pages have no timeline, so don't look for the tags in them
+		if !strings.Contains(strings.ToLower(page.Text), "timeline") {
+			return nil
+		}
+		blocks := timeline.FindTimelines(page.Text)
+		for i, b := range blocks {
+			wanted := pageFileName(page.Title)
+			if len(blocks) > 1 {
+				wanted = fmt.Sprintf("%s.%d", wanted, i+1)
+			}
+			name := used.unique(wanted)
+			if name != wanted {
+				sugar.Warnf("%q is already the name of another page's timeline; writing the one in %q to %q instead",
+					wanted, page.Title, name)
+			}
+			dataFile := filepath.Join(*outputDir, name+".data")
+			if err := os.WriteFile(dataFile, []byte(b.Source), 0o644); err != nil {
+				return err
+			}
+			found++
+
+			tl, err := timeline.ParseTimeline(ctx, b.Source, timeline.WithFileName(dataFile),
+				timeline.WithClock(clock), timeline.WithMode(parseMode))
+			if err != nil {
+				failed++
+				fmt.Fprintf(&failures, "%s (timeline %d, line %d of the page):\n", page.Title, i+1, b.Line)
+				for _, line := range strings.Split(err.Error(), "\n") {
+					fmt.Fprintf(&failures, "    %s\n", line)
+				}
+				sugar.Warnf("couldn't parse the timeline in %q: %s", page.Title, err.Error())
+				continue
+			}
+			if *render {
+				drawing.apply(tl)
+				output := filepath.Join(*outputDir, name+".png")
+				if err := draw2dimg.SaveToPngFile(output, tl.DrawTimeline(ctx)); err != nil {
+					return fmt.Errorf("couldn't write output to \"%s\": %w", output, err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		sugar.Fatalf("couldn't read the dump %q: %s", fs.Arg(0), err.Error())
+	}
+
+	failuresFile := filepath.Join(*outputDir, failuresFileName)
+	if err := os.WriteFile(failuresFile, []byte(failures.String()), 0o644); err != nil {
+		sugar.Fatalf("%s", err.Error())
+	}
+	sugar.Infof("wrote %d timelines to \"%s\"; %d couldn't be parsed (see \"%s\")",
+		found, *outputDir, failed, failuresFile)
+}
+
+// pageFileName makes a page title into a file name the way Wikipedia
+// makes it into a URL, with underscores for spaces, and with
+// underscores for the characters that can't be in a file name either
+var pageFileName = strings.NewReplacer(
+	" ", "_",
+	"/", "_",
+	"\\", "_",
+	":", "_",
+	"*", "_",
+	"?", "_",
+	"\"", "_",
+	"<", "_",
+	">", "_",
+	"|", "_",
+).Replace
+
+// fileNames are the names already given to timelines; they are
+// compared without case, for file systems that don't tell "AC_DC" and
+// "Ac_Dc" apart
+type fileNames map[string]bool
+
+// unique returns name, or, if it has been used, name with the first
+// number from 2 up that makes it unused, and marks it as used
+func (used fileNames) unique(name string) string {
+	candidate := name
+	for n := 2; used[strings.ToLower(candidate)]; n++ {
+		candidate = fmt.Sprintf("%s_%d", name, n)
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
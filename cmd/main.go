@@ -6,6 +6,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -23,74 +24,81 @@ func main() {
 	ctx = zax.Set(ctx, logger, []zap.Field{})
 	sugar := logger.Sugar()
 
-	// DMSans: https://fonts.google.com/specimen/DM+Sans
-	// ComputerModernRoman: https://sourceforge.net/projects/cm-unicode/
-	// Luxi: https://go.dev/blog/go-fonts
-	fontList := []string{"DMSans", "ComputerModernRoman", "Luxi"}
+	if len(os.Args) > 1 && os.Args[1] == "extract" {
+		extract(ctx, os.Args[2:])
+		return
+	}
 
 	textOutput := flag.Bool("t", false, "enable verbose text output")
 	jsonOutput := flag.Bool("j", false, "enable verbose JSON output")
-	majorTicSize := flag.Int("tM", 8, "length of major tics on x-axis (px)")
-	minorTicSize := flag.Int("tm", 5, "length of major tics on x-axis (px)")
-	labelBarGap := flag.Int("labelbargap", 5, "gap between the label and the start of the bar (px)")
 	outputFileName := flag.String("o", "", "name of the output file (default: inputfile+.png)")
-	font := flag.String("font", "DMSans", fmt.Sprintf("one of: %s", strings.Join(fontList, ", ")))
-	fontsize := flag.Int("fontsize", 12, "font size (pts)")
-	leading := flag.Int("leading", 8, "leading (gap between lines of text in px)")
-	margin := flag.Float64("margin", 5, "margin (px)")
-	borderColor := flag.String("border-color", "black", "color of the border around the image")
-	borderWidth := flag.Float64("border-width", 1, "width of the border around the image")
+	drawing := addDrawFlags(flag.CommandLine)
+	block := flag.String("block", "1", "which timeline to draw from a file with more than one, like a wiki article:\na number counting from 1, or \"all\" to draw each of them into its own file")
+	mode := flag.String("mode", "normal", modeUsage)
+	now := flag.String("now", "", "the time to use for \"now\" in the data file, like 2025-03-01 or 2025-03-01T12:00:00Z\n(default: $SOURCE_DATE_EPOCH if it is set, otherwise the current time)")
 	flag.Parse()
 	args := flag.Args()
 
 	if len(args) != 1 {
 		fmt.Fprintf(os.Stderr, "Usage: timeline [options] [filename]\n")
+		fmt.Fprintf(os.Stderr, "       timeline extract [options] pages-articles.xml[.bz2]\n")
 		flag.Usage()
 		os.Exit(1)
 	}
 	fullRawTimelineData := readfile(ctx, args[0])
 
-	tl, err := timeline.ParseTimeline(ctx, fullRawTimelineData)
-
+	clock, err := getClock(*now)
+	if err != nil {
+		sugar.Fatalf("%s", err.Error())
+	}
+	parseMode, err := getMode(*mode)
 	if err != nil {
-		sugar.Fatalf("Error parsing timeline data: %v\n", err.Error())
+		sugar.Fatalf("%s", err.Error())
 	}
+	blocks, err := selectBlocks(fullRawTimelineData, *block)
+	if err != nil {
+		sugar.Fatalf("%s", err.Error())
+	}
+	for _, b := range blocks {
+		tl, err := timeline.ParseTimeline(ctx, b.Source, timeline.WithFileName(args[0]),
+			timeline.WithStartLine(b.Line), timeline.WithClock(clock), timeline.WithMode(parseMode))
 
-	tl.Defaults.MajorTicSize = float64(*majorTicSize)
-	tl.Defaults.MinorTicSize = float64(*minorTicSize)
-	tl.Defaults.LabelBarGap = *labelBarGap
-	tl.Defaults.FontFace = *font
-	tl.Defaults.FontSize = *fontsize
-	tl.Defaults.FontLeading = *leading
-	tl.Defaults.Margin = *margin
-	tl.Defaults.BorderColor = *borderColor
-	tl.Defaults.BorderWidth = *borderWidth
+		if err != nil {
+			sugar.Fatalf("Error parsing timeline data: %v\n", err.Error())
+		}
+		reportDiagnostics(ctx, tl)
 
-	drawing := tl.DrawTimeline(ctx)
+		drawing.apply(tl)
+		image := tl.DrawTimeline(ctx)
 
-	if *textOutput == true {
-		printData(tl)
-	}
-	if *jsonOutput == true {
-		jsonString, err := json.MarshalIndent(tl, "", "    ")
-		if err != nil {
-			sugar.Fatalf("Couldn't convert data to JSON: %w\n", err.Error())
+		if *textOutput == true {
+			printData(tl)
+		}
+		if *jsonOutput == true {
+			jsonString, err := json.MarshalIndent(tl, "", "    ")
+			if err != nil {
+				sugar.Fatalf("Couldn't convert data to JSON: %w\n", err.Error())
+			}
+			fmt.Printf("%s\n", string(jsonString))
 		}
-		fmt.Printf("%s\n", string(jsonString))
-	}
 
-	var output string
-	if *outputFileName == "" {
-		output = args[0] + ".png"
-	} else {
-		output = *outputFileName
-	}
+		var output string
+		if *outputFileName == "" {
+			output = args[0] + ".png"
+		} else {
+			output = *outputFileName
+		}
+		if len(blocks) > 1 {
+			// number the files so the timelines don't overwrite each other
+			output = fmt.Sprintf("%s.%d.png", strings.TrimSuffix(output, ".png"), b.Number)
+		}
 
-	err = draw2dimg.SaveToPngFile(output, drawing)
-	if err != nil {
-		sugar.Fatalf("couldn't write output to \"%s\": %s", output, err.Error())
+		err = draw2dimg.SaveToPngFile(output, image)
+		if err != nil {
+			sugar.Fatalf("couldn't write output to \"%s\": %s", output, err.Error())
+		}
+		sugar.Infof("wrote chart to \"%s\"", output)
 	}
-	sugar.Infof("wrote chart to \"%s\"", output)
 
 }
 
@@ -132,8 +140,8 @@ func printData(timeline *timeline.Timeline) {
 		fmt.Printf("%s (w%d) | From: %s | Til: %s | Role: %s | Text: %s\n",
 			barInfo.Text,
 			item.Width,
-			item.From.Format(time.RFC822),
-			item.Til.Format(time.RFC822),
+			item.From,
+			item.Til,
 			colorInfo.Legend,
 			item.Text,
 		)
@@ -149,6 +157,139 @@ func printData(timeline *timeline.Timeline) {
 
 }
 
+// drawFlags are the flags for how a chart is drawn, which both
+// drawing a file and extracting from a dump use
+type drawFlags struct {
+	fs                         *flag.FlagSet
+	majorTicSize, minorTicSize int
+	labelBarGap                int
+	font                       string
+	fontSize, leading          int
+	margin                     float64
+	borderColor                string
+	borderWidth                float64
+}
+
+func addDrawFlags(fs *flag.FlagSet) *drawFlags {
+	// DMSans: https://fonts.google.com/specimen/DM+Sans
+	// ComputerModernRoman: https://sourceforge.net/projects/cm-unicode/
+	// Luxi: https://go.dev/blog/go-fonts
+	fontList := []string{"DMSans", "ComputerModernRoman", "Luxi"}
+
+	d := &drawFlags{fs: fs}
+	fs.IntVar(&d.majorTicSize, "tM", 8, "length of major tics on x-axis (px)")
+	fs.IntVar(&d.minorTicSize, "tm", 5, "length of major tics on x-axis (px)")
+	fs.IntVar(&d.labelBarGap, "labelbargap", 5, "gap between the label and the start of the bar (px)")
+	fs.StringVar(&d.font, "font", "DMSans", fmt.Sprintf("one of: %s", strings.Join(fontList, ", ")))
+	fs.IntVar(&d.fontSize, "fontsize", 12, "font size (pts)")
+	fs.IntVar(&d.leading, "leading", 8, "leading (gap between lines of text in px)")
+	fs.Float64Var(&d.margin, "margin", 5, "margin (px)")
+	fs.StringVar(&d.borderColor, "border-color", "black", "color of the border around the image")
+	fs.Float64Var(&d.borderWidth, "border-width", 1, "width of the border around the image")
+	return d
+}
+
+func (d *drawFlags) apply(tl *timeline.Timeline) {
+	tl.Defaults.MajorTicSize = float64(d.majorTicSize)
+	tl.Defaults.MinorTicSize = float64(d.minorTicSize)
+	tl.Defaults.LabelBarGap = d.labelBarGap
+	tl.Defaults.FontFace = d.font
+	tl.Defaults.FontSize = d.fontSize
+	// the bar labels have their own size unless -fontsize is given
+	d.fs.Visit(func(f *flag.Flag) {
+		if f.Name == "fontsize" {
+			tl.Defaults.LabelFontSize = d.fontSize
+		}
+	})
+	tl.Defaults.FontLeading = d.leading
+	tl.Defaults.Margin = d.margin
+	tl.Defaults.BorderColor = d.borderColor
+	tl.Defaults.BorderWidth = d.borderWidth
+}
+
+const modeUsage = "how to deal with problems in the data file: \"normal\" stops at data it can't use, like a bad date,\n" +
+	"\"lenient\" skips anything it can't use and draws the rest, \"strict\" stops at anything it doesn't understand"
+
+// getMode reads the -mode flag
+func getMode(mode string) (timeline.Mode, error) {
+	switch mode {
+	case "normal":
+		return timeline.Normal, nil
+	case "lenient":
+		return timeline.Lenient, nil
+	case "strict":
+		return timeline.Strict, nil
+	}
+	return 0, fmt.Errorf("-mode %q must be normal, lenient or strict", mode)
+}
+
+// reportDiagnostics logs the problems that were skipped while parsing
+// a timeline, with a summary of what isn't supported at the end
+func reportDiagnostics(ctx context.Context, tl *timeline.Timeline) {
+	sugar := zax.Get(ctx).Sugar()
+	for _, d := range tl.Diagnostics {
+		if !d.Ignored {
+			sugar.Warnf("skipped: %s", d.Error())
+		}
+	}
+	if unsupported := tl.Diagnostics.Unsupported(); len(unsupported) > 0 {
+		sugar.Warnf("not supported, so the chart will be different from Wikipedia's: %s",
+			strings.Join(unsupported, "; "))
+	}
+}
+
+// numberedBlock is a timeline from the input and which one it is,
+// counting from 1
+type numberedBlock struct {
+	timeline.Block
+	Number int
+}
+
+// selectBlocks finds the timelines in the input and picks the one
+// that -block asks for, or all of them; input without any timeline
+// tags is a single timeline
+func selectBlocks(data, which string) ([]numberedBlock, error) {
+	found := timeline.FindTimelines(data)
+	if len(found) == 0 {
+		found = []timeline.Block{{Source: data, Line: 1}}
+	}
+	var blocks []numberedBlock
+	for i, b := range found {
+		blocks = append(blocks, numberedBlock{Block: b, Number: i + 1})
+	}
+	if which == "all" {
+		return blocks, nil
+	}
+	n, err := strconv.Atoi(which)
+	if err != nil || n < 1 || n > len(blocks) {
+		return nil, fmt.Errorf("-block %q must be \"all\" or a number from 1 to %d", which, len(blocks))
+	}
+	return blocks[n-1 : n], nil
+}
+
+// getClock is the clock for "now" in the data file: the -now flag, or
+// SOURCE_DATE_EPOCH (see https://reproducible-builds.org/specs/source-date-epoch/)
+// so that a release can be drawn again exactly, or the current time
+func getClock(now string) (func() time.Time, error) {
+	if now != "" {
+		for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+			if t, err := time.Parse(layout, now); err == nil {
+				return func() time.Time { return t }, nil
+			}
+		}
+		return nil, fmt.Errorf("couldn't read -now %q (not like 2025-03-01 or 2025-03-01T12:00:00Z)", now)
+	}
+	if epoch, ok := os.LookupEnv("SOURCE_DATE_EPOCH"); ok {
+		seconds, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read SOURCE_DATE_EPOCH %q (not a number of seconds)", epoch)
+		}
+		t := time.Unix(seconds, 0).UTC()
+		return func() time.Time { return t }, nil
+	}
+	return time.Now, nil
+}
+
 func readfile(ctx context.Context, filename string) string {
 	logger := zax.Get(ctx)
 	content, err := os.ReadFile(filename)
@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"image/color"
 	"log/slog"
+	"math"
 	"os"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
 var Colorname = map[string][3]float64{
@@ -55,36 +57,101 @@ var Colorname = map[string][3]float64{
 	"lightgray":   {0.85, 0.85, 0.85},
 }
 
-func GetRGBAfromName(name string) color.RGBA {
+// colorFuncRe matches the gray(x), rgb(r,g,b) and hsb(h,s,b) color
+// values, whose numbers are all between 0 and 1
+var colorFuncRe = regexp.MustCompile(`^(gray|rgb|hsb)\(([^()]*)\)$`)
 
-	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+// ParseColor reads a color value, which is one of the Ploticus names
+// in Colorname, gray(x), rgb(r,g,b), hsb(h,s,b), #RRGGBB or #RRGGBBAA
+func ParseColor(value string) (color.RGBA, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
 
-	// handle `gray(0.3)` color type
-	grayRe := regexp.MustCompile(`gray\(((?:\d+(?:\.\d*)?|\.\d+))\)`)
-	matches := grayRe.FindStringSubmatch(name)
-	if len(matches) == 2 {
-		grayValue, err := strconv.ParseFloat(matches[1], 64)
+	if hex, ok := strings.CutPrefix(value, "#"); ok {
+		if len(hex) != 6 && len(hex) != 8 {
+			return color.RGBA{}, fmt.Errorf("color %q must be #RRGGBB or #RRGGBBAA", value)
+		}
+		if len(hex) == 6 {
+			hex += "ff"
+		}
+		n, err := strconv.ParseUint(hex, 16, 32)
 		if err != nil {
-			logger.Error(err.Error())
+			return color.RGBA{}, fmt.Errorf("color %q is not a hex color", value)
+		}
+		return color.RGBA{R: uint8(n >> 24), G: uint8(n >> 16), B: uint8(n >> 8), A: uint8(n)}, nil
+	}
+
+	if matches := colorFuncRe.FindStringSubmatch(value); matches != nil {
+		var v []float64
+		for field := range strings.SplitSeq(matches[2], ",") {
+			f, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil || f < 0 || f > 1 {
+				return color.RGBA{}, fmt.Errorf("color %q must have numbers between 0 and 1", value)
+			}
+			v = append(v, f)
 		}
-		return color.RGBA{
-			R: uint8(grayValue * 255),
-			G: uint8(grayValue * 255),
-			B: uint8(grayValue * 255),
-			A: 0xff, // an Alpha channel of 255 (aka 1, aka 0xff) is opaque
+		switch {
+		case matches[1] == "gray" && len(v) == 1:
+			return rgba(v[0], v[0], v[0]), nil
+		case matches[1] == "rgb" && len(v) == 3:
+			return rgba(v[0], v[1], v[2]), nil
+		case matches[1] == "hsb" && len(v) == 3:
+			return rgba(hsbToRGB(v[0], v[1], v[2])), nil
 		}
+		return color.RGBA{}, fmt.Errorf("color %q has the wrong number of values", value)
 	}
 
-	// handle defined color types
-	if _, ok := Colorname[name]; !ok {
-		logger.Warn(fmt.Sprintf("Color \"%s\" is not available; substituting black", name))
-		return color.RGBA{R: 0xff, G: 0xff, B: 0xff}
+	if value == "transparent" {
+		return color.RGBA{}, nil
 	}
+	if c, ok := Colorname[value]; ok {
+		return rgba(c[0], c[1], c[2]), nil
+	}
+	return color.RGBA{}, fmt.Errorf("color %q is not a color name, gray(), rgb(), hsb() or #hex", value)
+}
 
+// GetRGBAfromName is ParseColor for colors that have already been
+// checked; anything that isn't a color is drawn in black
+func GetRGBAfromName(name string) color.RGBA {
+	c, err := ParseColor(name)
+	if err != nil {
+		logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+		logger.Warn(fmt.Sprintf("%s; substituting black", err.Error()))
+		return color.RGBA{A: 0xff}
+	}
+	return c
+}
+
+// rgba makes an opaque color from red, green and blue between 0 and 1
+func rgba(r, g, b float64) color.RGBA {
 	return color.RGBA{
-		R: uint8(Colorname[name][0] * 255),
-		G: uint8(Colorname[name][1] * 255),
-		B: uint8(Colorname[name][2] * 255),
+		R: uint8(r * 255),
+		G: uint8(g * 255),
+		B: uint8(b * 255),
 		A: 0xff, // an Alpha channel of 255 (aka 1, aka 0xff) is opaque
 	}
 }
+
+// hsbToRGB converts hue, saturation and brightness, all between 0 and
+// 1, to red, green and blue
+func hsbToRGB(h, s, b float64) (float64, float64, float64) {
+	h = math.Mod(h, 1) * 6
+	sector := math.Floor(h)
+	f := h - sector
+	p := b * (1 - s)
+	q := b * (1 - s*f)
+	t := b * (1 - s*(1-f))
+	switch int(sector) {
+	case 0:
+		return b, t, p
+	case 1:
+		return q, b, p
+	case 2:
+		return p, b, t
+	case 3:
+		return p, q, b
+	case 4:
+		return t, p, b
+	default:
+		return b, p, q
+	}
+}
@@ -8,7 +8,7 @@ import (
 
 func TestNoColor(t *testing.T) {
 	rgb := GetRGBAfromName("not a color")
-	black := color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0x00}
+	black := color.RGBA{R: 0x00, G: 0x00, B: 0x00, A: 0xff}
 	if !reflect.DeepEqual(rgb, black) {
 		t.Error()
 	}
@@ -16,8 +16,39 @@ func TestNoColor(t *testing.T) {
 
 func TestOrange(t *testing.T) {
 	rgb := GetRGBAfromName("orange")
-	orange := color.RGBA{R: 0xff, G: 0x00, B: 0x00, A: 0xff}
+	orange := color.RGBA{R: 0xff, G: 0x9e, B: 0x23, A: 0xff}
 	if !reflect.DeepEqual(rgb, orange) {
 		t.Error()
 	}
 }
+
+func TestParseColor(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		want  color.RGBA
+	}{
+		{"red", color.RGBA{R: 0xff, A: 0xff}},
+		{"Red", color.RGBA{R: 0xff, A: 0xff}},
+		{"transparent", color.RGBA{}},
+		{"gray(0.5)", color.RGBA{R: 0x7f, G: 0x7f, B: 0x7f, A: 0xff}},
+		{"rgb(1,0.5,0)", color.RGBA{R: 0xff, G: 0x7f, A: 0xff}},
+		{"hsb(0.5,1,1)", color.RGBA{G: 0xff, B: 0xff, A: 0xff}},
+		{"#336699", color.RGBA{R: 0x33, G: 0x66, B: 0x99, A: 0xff}},
+		{"#33669980", color.RGBA{R: 0x33, G: 0x66, B: 0x99, A: 0x80}},
+	} {
+		got, err := ParseColor(tc.value)
+		if err != nil {
+			t.Errorf("%s: %v", tc.value, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.value, got, tc.want)
+		}
+	}
+
+	for _, value := range []string{"not a color", "rgb(1,2,3)", "rgb(1,0)", "gray(x)", "#12345", "#zzzzzz"} {
+		if _, err := ParseColor(value); err == nil {
+			t.Errorf("%s: expected an error", value)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package timeline
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// numericDateFormat is the DateFormat for timelines whose dates are
+// just numbers, like geological or astronomical ones
+const numericDateFormat = "x.y"
+
+// Date is a point on the time axis. Usually it is a calendar date, but
+// with "DateFormat = x.y" it is a number, like -4600 for a timeline in
+// millions of years, and Time is unset.
+type Date struct {
+	time.Time
+	Numeric bool
+	Value   float64 // only for numeric dates
+}
+
+// CalendarDate makes a Date from a time
+func CalendarDate(t time.Time) Date {
+	return Date{Time: t}
+}
+
+// NumericDate makes a Date from a number on an x.y scale
+func NumericDate(v float64) Date {
+	return Date{Numeric: true, Value: v}
+}
+
+// coord is where the date is on the time axis, as a number that can
+// only be compared with the coords of other dates of the same kind
+func (d Date) coord() float64 {
+	if d.Numeric {
+		return d.Value
+	}
+	// not UnixNano, which only works from 1678 to 2262
+	return float64(d.Unix()) + float64(d.Nanosecond())/1e9
+}
+
+// IsZero reports whether the date is unset; a numeric date is always
+// set, even if it is 0
+func (d Date) IsZero() bool {
+	return !d.Numeric && d.Time.IsZero()
+}
+
+// String is the number for a numeric date and the time for a
+// calendar one
+func (d Date) String() string {
+	if d.Numeric {
+		return strconv.FormatFloat(d.Value, 'f', -1, 64)
+	}
+	return d.Time.String()
+}
+
+// MarshalJSON writes a numeric date as its number and a calendar date
+// as its time, instead of the zero time that is in a numeric date
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.Numeric {
+		return json.Marshal(d.Value)
+	}
+	return d.Time.MarshalJSON()
+}
+
+// UnmarshalJSON reads a date written by MarshalJSON
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var v float64
+	if err := json.Unmarshal(data, &v); err == nil {
+		*d = NumericDate(v)
+		return nil
+	}
+	*d = Date{}
+	return d.Time.UnmarshalJSON(data)
+}
+
+// label is how the date is written on the time axis, using a format
+// like "mmm yyyy"; numeric dates are just the number
+func (d Date) label(format string) string {
+	if d.Numeric {
+		return strconv.FormatFloat(d.Value, 'f', -1, 64)
+	}
+	return d.Format(dateFormatLayout.Replace(format))
+}
+
+// dateFormatLayout turns a format like "dd mmm yyyy" into a Go time
+// layout; the longer codes come first so "mmm" isn't read as "mm"
+var dateFormatLayout = strings.NewReplacer(
+	"yyyy", "2006",
+	"mmmm", "January",
+	"mmm", "Jan",
+	"mm", "01",
+	"dd", "02",
+	"yy", "06",
+)
+
+// ticFormat is the format of the tic labels for a scale: its own
+// format: if it has one, otherwise the TimeAxis format for years or a
+// short month or day
+func (t *Timeline) ticFormat(scale Scale) string {
+	if scale.Format != "" {
+		return scale.Format
+	}
+	switch scale.Unit {
+	case "month":
+		return "mmm yyyy"
+	case "day":
+		return "dd mmm"
+	}
+	return t.Config.TimeAxis.Format
+}
+
+// tics are the dates of the tics on a scale, every Increment units
+// from its start, or the beginning of the Period if it has none, that
+// are in the Period
+func (t *Timeline) tics(scale Scale) []Date {
+	step := scale.Increment
+	if step <= 0 {
+		return nil
+	}
+	period := t.Config.Period
+	var dates []Date
+	if period.Start.Numeric {
+		first := period.Start.Value
+		if !scale.Start.IsZero() {
+			first = scale.Start.Value
+		}
+		for v := first; v <= period.End.Value; v += float64(step) {
+			if v >= period.Start.Value {
+				dates = append(dates, NumericDate(v))
+			}
+		}
+		return dates
+	}
+
+	// months and days start at midnight in the timeline's time zone
+	loc := t.opts.location
+	if loc == nil {
+		loc = period.Start.Location()
+	}
+	first := scale.Start.In(loc)
+	if scale.Start.IsZero() {
+		// the first whole unit in the Period
+		s := period.Start.In(loc)
+		switch scale.Unit {
+		case "month":
+			first = time.Date(s.Year(), s.Month(), 1, 0, 0, 0, 0, loc)
+		case "day":
+			first = time.Date(s.Year(), s.Month(), s.Day(), 0, 0, 0, 0, loc)
+		default:
+			first = time.Date(s.Year(), time.January, 1, 0, 0, 0, 0, loc)
+		}
+	}
+	for i := 0; ; i++ {
+		var d time.Time
+		switch scale.Unit {
+		case "month":
+			d = first.AddDate(0, i*step, 0)
+		case "day":
+			d = first.AddDate(0, 0, i*step)
+		default:
+			d = first.AddDate(i*step, 0, 0)
+		}
+		if d.After(period.End.Time) {
+			break
+		}
+		// tics before the Period would be off the chart
+		if !d.Before(period.Start.Time) {
+			dates = append(dates, CalendarDate(d))
+		}
+	}
+	return dates
+}
@@ -3,13 +3,8 @@ package timeline
 import (
 	"context"
 	_ "embed"
-	"fmt"
 	"image"
 	"image/color"
-	"math"
-	"slices"
-	"strings"
-	"time"
 
 	"github.com/golang/freetype/truetype"
 	"github.com/llgcode/draw2d"
@@ -24,22 +19,62 @@ var DMSans []byte
 //go:embed fonts/cmunrm.ttf
 var CM []byte
 
-func (t *Timeline) DrawTimeline(ctx context.Context) *image.RGBA {
+// DefaultImageWidth is the width of the image, in pixels, when the
+// ImageSize has no width:
+const DefaultImageWidth = 800
+
+// DefaultVerticalChartHeight is the height of the chart, in pixels, of
+// a vertical timeline whose ImageSize has no height: (or height:auto);
+// without a PlotArea the image is twice that, to make room for the
+// legend
+const DefaultVerticalChartHeight = 600
+
+// DrawTimeline draws the timeline with the options it was parsed with;
+// any options given here replace those for this drawing only. Of the
+// options, only WithLocation changes how a timeline is drawn.
+func (t *Timeline) DrawTimeline(ctx context.Context, opts ...Option) *image.RGBA {
+	logger := zax.Get(ctx)
+	if len(opts) > 0 {
+		parsed := t.opts
+		defer func() { t.opts = parsed }()
+		for _, opt := range opts {
+			opt(&t.opts)
+		}
+	}
 	if t.Config.ImageSize.WidthPx == 0 {
-		t.Config.ImageSize.WidthPx = 800
+		t.Config.ImageSize.WidthPx = DefaultImageWidth
 	}
-	if t.Config.ImageSize.HeightPx == 0 {
-		t.Config.ImageSize.HeightPx = float64(len(t.Bars)*
-			(t.Defaults.FontSize+t.Defaults.FontLeading) +
-			t.Defaults.FontLeading)
+	chartHeight := float64(len(t.barRows()))*t.barIncrement() + float64(t.Defaults.FontLeading)
+	if t.vertical() {
+		// the height of a vertical timeline doesn't depend on the
+		// number of bars
+		chartHeight = DefaultVerticalChartHeight
+	}
+	var height float64
+	if t.Config.PlotArea.Defined {
+		// with a PlotArea the ImageSize is the whole image, and an
+		// automatic height is the chart plus the margins around it
+		if t.Config.ImageSize.HeightPx == 0 {
+			t.Config.ImageSize.HeightPx = chartHeight +
+				t.Config.PlotArea.Top.Pixels(0) + t.Config.PlotArea.Bottom.Pixels(0)
+		}
+		height = t.Config.ImageSize.HeightPx
+	} else {
+		// without one the ImageSize is the chart and the legend
+		// goes in the extra space below it
+		if t.Config.ImageSize.HeightPx == 0 {
+			t.Config.ImageSize.HeightPx = chartHeight
+		}
+		height = t.Config.ImageSize.HeightPx * 2
 	}
-	height := t.Config.ImageSize.HeightPx * 2
 
 	imageData := image.NewRGBA(image.Rect(0, 0, int(t.Config.ImageSize.WidthPx), int(height)))
 	t.Defaults.GraphicsContext = draw2dimg.NewGraphicContext(imageData)
 	gc := t.Defaults.GraphicsContext
 
-	// draw a white box with a black edge to put everything into
+	// draw a box (white, unless there is a canvas color) with a
+	// black edge to put everything into
+	gc.SetFillColor(t.backgroundColor(t.Config.BackgroundColors.Canvas, color.RGBA{255, 255, 255, 255}))
 	gc.SetStrokeColor(GetRGBAfromName(t.Defaults.BorderColor))
 	gc.SetLineWidth(t.Defaults.BorderWidth)
 	draw2dkit.Rectangle(gc, 0, 0, float64(t.Config.ImageSize.WidthPx), float64(height))
@@ -50,223 +85,310 @@ func (t *Timeline) DrawTimeline(ctx context.Context) *image.RGBA {
 
 	// find the widest text
 	var maxLabelWidth float64
-	for _, item := range t.PlotItems {
-		person := strings.ReplaceAll(t.Bars[item.BarID].Text, "\"", "")
-		left, _, right, _ := gc.GetStringBounds(person)
-		width := right - left
-		if width > maxLabelWidth {
-			maxLabelWidth = width
+	gc.SetFontSize(t.labelFontSize())
+	for _, row := range t.barRows() {
+		for _, line := range row.lines() {
+			left, _, right, _ := gc.GetStringBounds(line)
+			maxLabelWidth = max(maxLabelWidth, right-left)
 		}
 	}
+	gc.SetFontSize(float64(t.Defaults.FontSize))
 	t.Derived.MaxLabelWidth = maxLabelWidth
-	t.Derived.BarLeft = maxLabelWidth + float64(t.Defaults.LabelBarGap)
-	t.Derived.TotalBarPixels = t.Config.ImageSize.WidthPx - maxLabelWidth -
-		t.Defaults.Margin - float64(t.Defaults.LabelBarGap)
-	fmt.Printf("barleft: %f  maxlabelwidth: %f  labelbargap: %d\n", t.Derived.BarLeft, t.Derived.MaxLabelWidth,
-		t.Defaults.LabelBarGap)
+	legend := t.layoutLegend()
+	t.layoutPlotArea(height, legend)
+	if !t.vertical() && t.Derived.PlotLeft-float64(t.Defaults.LabelBarGap) < maxLabelWidth {
+		logger.Sugar().Warnf("the bar labels are wider than the left side of the PlotArea (%.0fpx); "+
+			"they will be cut off unless the font is smaller", t.Derived.PlotLeft)
+	}
+	logger.Sugar().Debugf("plot area: %f,%f to %f,%f  maxlabelwidth: %f  labelbargap: %d",
+		t.Derived.PlotLeft, t.Derived.PlotTop, t.Derived.PlotRight, t.Derived.PlotBottom,
+		t.Derived.MaxLabelWidth, t.Defaults.LabelBarGap)
+
+	// the background of the plot area
+	if t.Config.BackgroundColors.Back != "" {
+		gc.SetFillColor(t.backgroundColor(t.Config.BackgroundColors.Back, color.RGBA{}))
+		draw2dkit.Rectangle(gc, t.Derived.PlotLeft, t.Derived.PlotTop,
+			t.Derived.PlotRight, t.Derived.PlotBottom)
+		gc.Fill()
+	}
+
+	// add the people to the chart y-axis (or, for vertical
+	// timelines, the x-axis), with the empty bars behind them
+	t.AddPeople()
+
+	// gridlines over the empty bars, with the major ones over the
+	// minor ones
+	t.DrawGrid(t.Config.ScaleMinor)
+	t.DrawGrid(t.Config.ScaleMajor)
 
 	// chart borders
 	t.DrawBorders()
 
 	// minor x-axis tics
-	_ = t.DrawTics(false, t.Defaults.MinorTicSize, t.Config.ScaleMinor.Increment)
+	_ = t.DrawTics(false, t.Defaults.MinorTicSize, t.Config.ScaleMinor)
 	// major x-axis tics
-	yPos := t.DrawTics(true, t.Defaults.MajorTicSize, t.Config.ScaleMajor.Increment)
+	yPos := t.DrawTics(true, t.Defaults.MajorTicSize, t.Config.ScaleMajor)
 
-	// add the people to the chart y-axis
-	t.AddPeople()
-
-	// LineEvents are just albums/live things; we are ignoring the
-	// layer for now and drawing them on top
-	totalDuration := t.Config.Period.End.Sub(t.Config.Period.Start)
-	for _, e := range t.LineEvents {
-		gc.SetLineWidth(2)
-		gc.SetStrokeColor(GetRGBAfromName(t.Colors[e.ColorID].Value))
-		barFrac := float64(
-			e.Date.Sub(t.Config.Period.Start)) / float64(totalDuration)
-		gc.MoveTo(t.Derived.TotalBarPixels*barFrac+t.Derived.BarLeft+float64(t.Defaults.LabelBarGap), 0)
-		gc.LineTo(t.Derived.TotalBarPixels*barFrac+t.Derived.BarLeft+float64(t.Defaults.LabelBarGap),
-			t.Config.ImageSize.HeightPx)
-		gc.Stroke()
+	// LineData lines that go behind the bars, but over the empty bars
+	t.DrawLines("back", height)
 
+	// the bars from PlotData
+	t.DrawBars()
+	if t.vertical() {
+		// the legend goes under the bar labels
+		yPos = t.Derived.PlotBottom + t.labelFontSize()*4/3 +
+			float64(t.Defaults.LabelBarGap+t.Defaults.FontLeading) + float64(t.extraLabelLines())*t.labelLineHeight()
 	}
 
+	// LineData lines that go over the bars
+	t.DrawLines("front", height)
+
 	// make the legend
-	numLegendItemsPerColumn := int(math.Ceil(float64(len(t.Colors)) / float64(t.Config.LegendColumns)))
-	var col, i int
-	var colWidth float64
-	legendXpos := t.Derived.BarLeft
-	var legendItems []string
-	// get the legend items in the correct order
-	for _, plotItem := range t.PlotItems {
-		// don't add it to the list more than once
-		if slices.Contains(legendItems, plotItem.ColorID) {
-			continue
-		}
-		// PlotItems with Text don't need to go into the
-		// legend because they get the Text written on them in
-		// the chart
-		if plotItem.Text != "" {
-			continue
-		}
-		legendItems = append(legendItems, plotItem.ColorID)
+	if x, y := t.legendOrigin(yPos, height); len(legend.items) > 0 &&
+		(x+legend.width > t.Config.ImageSize.WidthPx || y+legend.height > height) {
+		logger.Sugar().Warnf("the legend (%.0fx%.0fpx at %.0f,%.0f) doesn't fit in the image (%.0fx%.0fpx); "+
+			"it will be cut off unless the image is bigger", legend.width, legend.height, x, y,
+			t.Config.ImageSize.WidthPx, height)
 	}
-	// add the line events to the end of the legend
-	for _, lineEvent := range t.LineEvents {
-		if slices.Contains(legendItems, lineEvent.ColorID) {
-			continue
-		}
-		legendItems = append(legendItems, lineEvent.ColorID)
+	t.DrawLegend(legend, yPos, height)
+
+	// captions and notes from TextData
+	t.DrawText(height)
+	return imageData
+}
+
+// DrawGrid draws a line across the plot area at each tic of a scale
+// that has a gridcolor
+func (t *Timeline) DrawGrid(scale Scale) {
+	if scale.GridColor == "" {
+		return
 	}
-	// draw the legend
-	gc.SetFontSize(12)
-	for _, legendItem := range legendItems {
-		for _, colorItem := range t.Colors {
-			if colorItem.ID != legendItem {
-				continue
-			}
-			left, top, right, bottom := gc.GetStringBounds(colorItem.Legend)
-			textSize := right - left
-			textPos := textSize +
-				float64(t.Config.MaxLineWidth) +
-				float64(t.Defaults.LabelBarGap) +
-				float64(t.Defaults.LabelBarGap)
-			if textPos > colWidth {
-				colWidth = textPos
-			}
-			if i == numLegendItemsPerColumn {
-				col++
-				legendXpos = legendXpos + colWidth
-				i = 0
-			}
-			y := yPos +
-				float64(i+1)*(float64(t.Defaults.FontSize)*4/3+float64(t.Defaults.LabelBarGap))
-			// draw little colored box for the legend (really a 13x13 line)
-			gc.SetStrokeColor(GetRGBAfromName(colorItem.Value))
-			gc.SetLineWidth(float64(t.Config.MaxLineWidth))
-			gc.MoveTo(legendXpos+float64(t.Defaults.LabelBarGap), y)
-			gc.LineTo(legendXpos+float64(t.Defaults.LabelBarGap)+
-				float64(t.Config.MaxLineWidth), y)
-			gc.Stroke()
-			// write the legend text
-			gc.FillStringAt(colorItem.Legend,
-				legendXpos+float64(t.Config.MaxLineWidth)+float64(t.Defaults.LabelBarGap)+
-					float64(t.Defaults.LabelBarGap),
-				y+(bottom-top)/2)
-			i++
-		}
+	gc := t.Defaults.GraphicsContext
+	acrossStart, acrossEnd := t.Derived.PlotTop, t.Derived.PlotBottom
+	if t.vertical() {
+		acrossStart, acrossEnd = t.Derived.PlotLeft, t.Derived.PlotRight
+	}
+	gc.SetLineWidth(1)
+	gc.SetStrokeColor(t.color(scale.GridColor))
+	for _, date := range t.tics(scale) {
+		along := t.timePixel(date)
+		gc.MoveTo(t.point(along, acrossStart))
+		gc.LineTo(t.point(along, acrossEnd))
+		gc.Stroke()
 	}
-	return imageData
 }
 
 func (t *Timeline) DrawTics(
 	hasTicLabel bool,
 	ticSize float64,
-	step int,
+	scale Scale,
 ) float64 {
 	var yPos float64
-	startYear := t.Config.ScaleMajor.Start
-	endYear := t.Config.Period.End.Year()
 	gc := t.Defaults.GraphicsContext
-	totalDuration := t.Config.Period.End.Sub(t.Config.Period.Start)
-	totalBarPixels := t.Derived.TotalBarPixels
-	chartHeight := t.Config.ImageSize.HeightPx
-	for i := startYear; i <= endYear; i = i + step {
-		ticFrac := float64(
-			time.Date(i, time.January, 1, 0, 0, 0, 0, time.Now().Location()).Sub(t.Config.Period.Start)) /
-			float64(totalDuration)
+	chartHeight := t.Derived.PlotBottom
+	format := t.ticFormat(scale)
+	// there are no tics without a ScaleMajor or ScaleMinor in the file
+	for _, date := range t.tics(scale) {
+		pos := t.timePixel(date)
+		label := date.label(format)
 		gc.SetFillColor(color.RGBA{0, 0, 0, 255})
 		gc.SetStrokeColor(color.RGBA{0, 0, 0, 255})
-		xpos := totalBarPixels*ticFrac + t.Derived.BarLeft + float64(t.Defaults.LabelBarGap)
-		gc.MoveTo(xpos, float64(chartHeight))
-		gc.LineTo(xpos, float64(chartHeight)+ticSize)
+		if t.vertical() {
+			// tics and their labels go on the left of the chart
+			gc.MoveTo(t.Derived.PlotLeft, pos)
+			gc.LineTo(t.Derived.PlotLeft-ticSize, pos)
+			gc.Stroke()
+			if hasTicLabel {
+				left, top, right, bottom := gc.GetStringBounds(label)
+				gc.FillStringAt(label,
+					t.Derived.PlotLeft-ticSize-float64(t.Defaults.LabelBarGap)-(right-left),
+					pos+(bottom-top)/2)
+			}
+			continue
+		}
+		gc.MoveTo(pos, float64(chartHeight))
+		gc.LineTo(pos, float64(chartHeight)+ticSize)
 		gc.Stroke()
 		if hasTicLabel {
-			left, top, right, bottom := gc.GetStringBounds(fmt.Sprintf("%d", i))
+			left, top, right, bottom := gc.GetStringBounds(label)
 			yPos = float64(chartHeight) + (bottom - top) + 8 + float64(t.Defaults.FontLeading)/2
-			gc.FillStringAt(fmt.Sprintf("%d", i),
-				xpos-((left+right)/2),
+			gc.FillStringAt(label,
+				pos-((left+right)/2),
 				yPos)
 		}
 	}
 	return yPos
 }
 
+// AddPeople writes the label of each bar and draws the bar empty
 func (t *Timeline) AddPeople() {
-	people := []string{}
+	rows := t.barRows()
 	gc := t.Defaults.GraphicsContext
 
-	// make a list of the people and find the widest text
-	var maxLabelWidth float64
-	for _, item := range t.PlotItems {
-		person := strings.ReplaceAll(t.Bars[item.BarID].Text, "\"", "")
-		left, _, right, _ := gc.GetStringBounds(person)
-		width := right - left
-		if width > maxLabelWidth {
-			maxLabelWidth = width
-		}
-		if !slices.Contains(people, person) {
-			people = append(people, person)
+	fontSize := t.labelFontSize()
+	lineHeight := t.labelLineHeight()
+	gc.SetFontSize(fontSize)
+	defer gc.SetFontSize(float64(t.Defaults.FontSize))
+	for i, row := range rows {
+		barPos := t.barPixel(i, len(rows))
+		lines := row.lines()
+		for j, line := range lines {
+			left, top, right, bottom := gc.GetStringBounds(line)
+			width := right - left
+			if t.vertical() {
+				// write the name centred under the bar
+				gc.FillStringAt(line, barPos-width/2,
+					t.Derived.PlotBottom+float64(t.Defaults.LabelBarGap)+(bottom-top)+float64(j)*lineHeight)
+			} else {
+				// write the name right-justified, with its lines
+				// centred on the bar
+				yPos := barPos + (0.5 * 0.75 * fontSize) +
+					(float64(j)-float64(len(lines)-1)/2)*lineHeight
+				gc.FillStringAt(line, t.Derived.PlotLeft-float64(t.Defaults.LabelBarGap)-width, yPos)
+			}
 		}
-	}
-
-	totalDuration := t.Config.Period.End.Sub(t.Config.Period.Start)
-	// people's names and their bars and any bar text
-	for i, person := range people {
-		// write the name right-justified
-		left, _, right, _ := gc.GetStringBounds(person)
-		width := right - left
-		padding := maxLabelWidth - width
-		yPos := float64(18 + i*(t.Defaults.FontSize+t.Defaults.FontLeading))
-		yBarPos := yPos - (0.5 * 0.75 * float64(t.Defaults.FontSize)) // convert pts to pixels, split
-		gc.FillStringAt(person, t.Defaults.Margin+padding, yPos)
-		// draw default gray bar
+		// draw the empty bar, gray unless there is a bars color
 		gc.SetLineWidth(float64(t.Config.DefaultLineWidth))
-		gc.SetStrokeColor(color.RGBA{R: 242, G: 242, B: 242, A: 255})
-		gc.MoveTo(t.Derived.BarLeft+float64(t.Defaults.LabelBarGap), yBarPos)
-		gc.LineTo(t.Derived.TotalBarPixels+t.Derived.BarLeft+float64(t.Defaults.LabelBarGap)-1, yBarPos)
+		gc.SetStrokeColor(t.backgroundColor(t.Config.BackgroundColors.Bars,
+			color.RGBA{R: 242, G: 242, B: 242, A: 255}))
+		gc.MoveTo(t.point(t.timePixel(t.Config.Period.Start), barPos))
+		gc.LineTo(t.point(t.timePixel(t.Config.Period.End), barPos))
 		gc.Stroke()
-		// draw some bars
+	}
+}
+
+// DrawBars draws the PlotData items on their bars, with their text
+func (t *Timeline) DrawBars() {
+	rows := t.barRows()
+	gc := t.Defaults.GraphicsContext
+
+	for i, row := range rows {
+		barPos := t.barPixel(i, len(rows))
 		for _, item := range t.PlotItems {
 
-			barInfo := t.Bars[item.BarID]
-			if person != strings.ReplaceAll(barInfo.Text, "\"", "") {
+			if item.BarID != row.ID || item.BarsetRow != row.Row {
 				continue
 			}
 			width := float64(item.Width)
-			barStartFrac := float64(item.From.Sub(t.Config.Period.Start)) /
-				float64(totalDuration)
-			barEndFrac := float64(item.Til.Sub(t.Config.Period.Start)) /
-				float64(totalDuration)
-			barSegmentStart := t.Derived.TotalBarPixels*barStartFrac +
-				t.Derived.BarLeft +
-				float64(t.Defaults.LabelBarGap)
-			barSegmentEnd := t.Derived.TotalBarPixels*barEndFrac +
-				t.Derived.BarLeft +
-				float64(t.Defaults.LabelBarGap)
-			if barSegmentEnd >= t.Config.ImageSize.WidthPx {
+			if item.Point {
+				// a point has no bar, just a mark across the
+				// bar it is on and maybe some text
+				at := t.timePixel(item.At)
+				if item.Mark.Style == "line" {
+					gc.SetStrokeColor(t.color(item.Mark.ColorID))
+					gc.SetLineWidth(2)
+					gc.MoveTo(t.point(at, barPos-width/2))
+					gc.LineTo(t.point(at, barPos+width/2))
+					gc.Stroke()
+				}
+				t.addBarText(item, at, at, barPos)
+				continue
+			}
+			barSegmentStart := t.timePixel(item.From)
+			barSegmentEnd := t.timePixel(item.Til)
+			if !t.vertical() && barSegmentEnd >= t.Config.ImageSize.WidthPx {
 				barSegmentEnd -= 5
 			}
-			gc.SetStrokeColor(GetRGBAfromName(t.Colors[item.ColorID].Value))
+			gc.SetStrokeColor(t.color(item.ColorID))
 			gc.SetLineWidth(width)
-			gc.MoveTo(barSegmentStart, yBarPos)
-			gc.LineTo(barSegmentEnd, yBarPos)
+			gc.MoveTo(t.point(barSegmentStart, barPos))
+			gc.LineTo(t.point(barSegmentEnd, barPos))
 			gc.Stroke()
-			if item.Text != "" {
-				top, _, _, bottom := gc.GetStringBounds(item.Text)
-				gc.SetFontSize(8)
-				// gc.SetFillColor(color.RGBA{R: 255, G: 255, B: 255, A: 255})
-				gc.SetFillColor(GetRGBAfromName(t.Config.PlotTextColor))
-				textYPos := yBarPos + (float64(t.Config.MaxLineWidth)-bottom+top)/4
-				gc.FillStringAt(item.Text, barSegmentStart+float64(t.Defaults.LabelBarGap),
-					textYPos)
-				gc.Stroke()
-				gc.SetFontSize(12)
-				gc.SetStrokeColor(color.RGBA{R: 0, G: 0, B: 0, A: 255})
-				gc.SetFillColor(color.RGBA{R: 0, G: 0, B: 0, A: 255})
+			t.addBarText(item, barSegmentStart, barSegmentEnd, barPos)
+		}
+	}
+}
+
+// addBarText writes the text for a PlotItem on its bar, which runs
+// from barSegmentStart to barSegmentEnd along the time axis
+func (t *Timeline) addBarText(item PlotItem, barSegmentStart, barSegmentEnd, barPos float64) {
+	if item.Text == "" {
+		return
+	}
+	gc := t.Defaults.GraphicsContext
+	size, textColor := t.Config.PlotTextSize, t.Config.PlotTextColor
+	if item.FontSize != (FontSize{}) {
+		size = item.FontSize
+	}
+	if item.TextColor != "" {
+		textColor = item.TextColor
+	}
+	gc.SetFontSize(t.points(size))
+	gc.SetFillColor(t.color(textColor))
+	left, top, right, bottom := gc.GetStringBounds(item.Text)
+	textWidth, textHeight := right-left, bottom-top
+	gap := float64(t.Defaults.LabelBarGap)
+
+	var textXPos, textYPos float64
+	if item.Align == "" && item.Anchor == "" && item.ShiftX == 0 && item.ShiftY == 0 {
+		// without any placement the text goes just inside the
+		// start of the bar
+		if t.vertical() {
+			textXPos = barPos - textWidth/2
+			textYPos = barSegmentStart - gap
+			if barSegmentEnd > barSegmentStart {
+				textYPos = barSegmentStart + gap + textHeight
+			}
+		} else {
+			textXPos = barSegmentStart + gap
+			textYPos = barPos + textHeight/2
+		}
+	} else {
+		// like EasyTimeline, the text starts with its baseline
+		// on the middle of the bar at the anchor and is moved
+		// from there by the shift, which is up and to the right
+		textXPos, textYPos = t.point(anchorPixel(item, barSegmentStart, barSegmentEnd), barPos)
+		switch item.Align {
+		case "center":
+			textXPos -= textWidth / 2
+		case "right":
+			textXPos -= textWidth
+		case "":
+			if t.vertical() {
+				textXPos -= textWidth / 2
 			}
 		}
+		textXPos += item.ShiftX
+		textYPos -= item.ShiftY
 	}
+
+	gc.FillStringAt(item.Text, textXPos, textYPos)
+	gc.Stroke()
+	gc.SetFontSize(12)
+	gc.SetStrokeColor(color.RGBA{R: 0, G: 0, B: 0, A: 255})
+	gc.SetFillColor(color.RGBA{R: 0, G: 0, B: 0, A: 255})
+}
+
+// anchorPixel is where along a bar from start to end the text of an
+// item is anchored; like EasyTimeline, that is the middle of the bar
+// unless the item has an anchor: from or till
+func anchorPixel(item PlotItem, start, end float64) float64 {
+	switch item.Anchor {
+	case "from":
+		return start
+	case "till":
+		return end
+	}
+	return (start + end) / 2
+}
+
+// backgroundColor looks up one of the BackgroundColors, which are IDs
+// from Colors
+func (t *Timeline) backgroundColor(id string, fallback color.RGBA) color.RGBA {
+	c, ok := t.Colors[id]
+	if !ok {
+		return fallback
+	}
+	return c.RGBA
+}
+
+// color is a color from Colors or, for something that isn't an ID in
+// Colors, a color value like "red" or "rgb(1,0,0)"
+func (t *Timeline) color(idOrValue string) color.RGBA {
+	if c, ok := t.Colors[idOrValue]; ok {
+		return c.RGBA
+	}
+	return GetRGBAfromName(idOrValue)
 }
 
 func (t *Timeline) DrawBorders() {
@@ -274,14 +396,28 @@ func (t *Timeline) DrawBorders() {
 	gc.SetLineWidth(1)
 	gc.SetFillColor(color.RGBA{0, 0, 0, 255})
 	gc.SetStrokeColor(color.RGBA{0, 0, 0, 255})
-	gc.MoveTo(t.Derived.BarLeft+float64(t.Defaults.LabelBarGap), 0)
-	gc.LineTo(t.Derived.BarLeft+float64(t.Defaults.LabelBarGap), t.Config.ImageSize.HeightPx)
+	gc.MoveTo(t.Derived.PlotLeft, t.Derived.PlotTop)
+	gc.LineTo(t.Derived.PlotLeft, t.Derived.PlotBottom)
 	gc.Stroke()
-	gc.MoveTo(t.Derived.BarLeft+float64(t.Defaults.LabelBarGap), t.Config.ImageSize.HeightPx)
-	gc.LineTo(t.Config.ImageSize.WidthPx-1, t.Config.ImageSize.HeightPx)
+	gc.MoveTo(t.Derived.PlotLeft, t.Derived.PlotBottom)
+	gc.LineTo(t.Derived.PlotRight-1, t.Derived.PlotBottom)
 	gc.Stroke()
 }
 
+// DefaultFontSizes are the points for each of the FontSize keywords
+var DefaultFontSizes = map[string]int{"XS": 7, "S": 8, "M": 10, "L": 12, "XL": 14}
+
+// points is the size of a font in points; it is 0 for a zero FontSize
+func (t *Timeline) points(size FontSize) float64 {
+	if size.Keyword == "" {
+		return float64(size.Points)
+	}
+	if points, ok := t.Defaults.FontSizes[size.Keyword]; ok {
+		return float64(points)
+	}
+	return float64(DefaultFontSizes[size.Keyword])
+}
+
 func (t *Timeline) SetFont(ctx context.Context) {
 	logger := zax.Get(ctx)
 
@@ -0,0 +1,65 @@
+package timeline
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+// Page is one page of a MediaWiki XML dump
+type Page struct {
+	Title string
+	Text  string // the wikitext of the latest revision in the dump
+}
+
+// dumpPage is how a page is written in the XML of a dump; a dump with
+// the history of each page has more than one revision
+type dumpPage struct {
+	Title     string `xml:"title"`
+	Revisions []struct {
+		Text string `xml:"text"`
+	} `xml:"revision"`
+}
+
+// ReadDump reads a MediaWiki XML dump, like pages-articles.xml from
+// https://dumps.wikimedia.org/, or the same compressed with bzip2, and
+// calls fn with each page in it. The dump is read a page at a time, so
+// it can be much bigger than memory. It stops at the first error from
+// fn and returns it.
+func ReadDump(r io.Reader, fn func(Page) error) error {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(3); bytes.Equal(magic, []byte("BZh")) {
+		r = bzip2.NewReader(br)
+	} else {
+		r = br
+	}
+
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "page" {
+			continue
+		}
+		var p dumpPage
+		if err := decoder.DecodeElement(&p, &start); err != nil {
+			return err
+		}
+		page := Page{Title: p.Title}
+		if len(p.Revisions) > 0 {
+			page.Text = p.Revisions[len(p.Revisions)-1].Text
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package timeline
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadDump(t *testing.T) {
+	dump := `<mediawiki xmlns="http://www.mediawiki.org/xml/export-0.11/" xml:lang="en">
+  <siteinfo><sitename>Wikipedia</sitename></siteinfo>
+  <page>
+    <title>The Cure</title>
+    <ns>0</ns>
+    <revision>
+      <id>1</id>
+      <text xml:space="preserve">old</text>
+    </revision>
+    <revision>
+      <id>2</id>
+      <text xml:space="preserve">== Members ==
+&lt;timeline&gt;
+ImageSize = width:800
+&lt;/timeline&gt;</text>
+    </revision>
+  </page>
+  <page>
+    <title>AC/DC</title>
+    <ns>0</ns>
+    <revision><text xml:space="preserve">No timeline here</text></revision>
+  </page>
+</mediawiki>
+`
+	var pages []Page
+	err := ReadDump(strings.NewReader(dump), func(p Page) error {
+		pages = append(pages, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 2 || pages[0].Title != "The Cure" || pages[1].Title != "AC/DC" {
+		t.Fatalf("pages are %+v", pages)
+	}
+	// the latest revision, with the entities decoded
+	blocks := FindTimelines(pages[0].Text)
+	if len(blocks) != 1 || blocks[0].Source != "\nImageSize = width:800\n" || blocks[0].Line != 2 {
+		t.Errorf("timelines in %q are %+v", pages[0].Text, blocks)
+	}
+
+	if err := ReadDump(strings.NewReader("<mediawiki><page><title>x"), func(Page) error { return nil }); err == nil {
+		t.Error("expected an error for a dump that stops part way through")
+	}
+}
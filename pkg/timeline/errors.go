@@ -0,0 +1,93 @@
+package timeline
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ParseError is a problem with one part of a timeline file, with
+// enough detail to find it in the file
+type ParseError struct {
+	File    string // empty if the name of the file isn't known
+	Line    int
+	Column  int
+	Token   string // the text that couldn't be used
+	Section string // the command the problem is in, e.g. "PlotData"
+	Msg     string
+	Ignored bool // something that isn't supported, which was skipped
+}
+
+func (e *ParseError) Error() string {
+	var sb strings.Builder
+	if e.File != "" {
+		sb.WriteString(e.File + ":")
+	}
+	fmt.Fprintf(&sb, "%d:%d: ", e.Line, e.Column)
+	if e.Section != "" {
+		sb.WriteString(e.Section + ": ")
+	}
+	sb.WriteString(e.Msg)
+	if e.Token != "" {
+		fmt.Fprintf(&sb, " (near %q)", e.Token)
+	}
+	return sb.String()
+}
+
+// ParseErrors is every problem found in a timeline file, in the order
+// they appear in the file
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap lets errors.As find the individual errors
+func (e ParseErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Unsupported sums up the things that were ignored because they aren't
+// supported, like "PlotData: shift: ignored", in the order they first
+// appear, so you know how far a chart is from the one on Wikipedia
+func (e ParseErrors) Unsupported() []string {
+	var summary []string
+	counts := make(map[string]int)
+	for _, err := range e {
+		if !err.Ignored {
+			continue
+		}
+		what := err.Msg
+		if err.Section != "" {
+			what = err.Section + ": " + err.Msg
+		}
+		if counts[what] == 0 {
+			summary = append(summary, what)
+		}
+		counts[what]++
+	}
+	for i, what := range summary {
+		if counts[what] > 1 {
+			summary[i] = fmt.Sprintf("%s (%d times)", what, counts[what])
+		}
+	}
+	return summary
+}
+
+// sortByPosition puts the errors in the order they appear in the file
+func (e ParseErrors) sortByPosition() {
+	slices.SortStableFunc(e, func(a, b *ParseError) int {
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+}
@@ -0,0 +1,229 @@
+package timeline
+
+import (
+	"slices"
+	"strings"
+)
+
+// Pixels converts a dimension to pixels; total is the size of the
+// image in the same direction and is only used for percentages
+func (d Dimension) Pixels(total float64) float64 {
+	if d.Percent {
+		return d.Value * total / 100
+	}
+	return d.Value
+}
+
+// Rect returns the edges of the plot area in pixels from the top left
+// of an image of the given size
+func (a PlotArea) Rect(imageWidth, imageHeight float64) (left, top, right, bottom float64) {
+	left = a.Left.Pixels(imageWidth)
+	if a.Width.Defined {
+		right = left + a.Width.Pixels(imageWidth)
+	} else {
+		right = imageWidth - a.Right.Pixels(imageWidth)
+	}
+	bottom = imageHeight - a.Bottom.Pixels(imageHeight)
+	if a.Height.Defined {
+		top = bottom - a.Height.Pixels(imageHeight)
+	} else {
+		top = a.Top.Pixels(imageHeight)
+	}
+	return left, top, right, bottom
+}
+
+// layoutPlotArea works out where the chart goes in an image of the
+// given height; without a PlotArea in the file the chart fills the
+// width of the image, less the labels and the margin
+func (t *Timeline) layoutPlotArea(imageHeight float64, legend legendLayout) {
+	d := &t.Derived
+	labels := t.Defaults.Margin + d.MaxLabelWidth + float64(t.Defaults.LabelBarGap)
+	if t.vertical() {
+		// the bar labels go under the chart and the tic labels
+		// go to the left of it
+		left, _, right, _ := t.Defaults.GraphicsContext.GetStringBounds(
+			t.Config.Period.End.label(t.ticFormat(t.Config.ScaleMajor)))
+		labels = t.Defaults.Margin + right - left + t.Defaults.MajorTicSize +
+			float64(t.Defaults.LabelBarGap)
+	}
+	if t.Config.PlotArea.Defined {
+		d.PlotLeft, d.PlotTop, d.PlotRight, d.PlotBottom =
+			t.Config.PlotArea.Rect(t.Config.ImageSize.WidthPx, imageHeight)
+		if !t.Config.PlotArea.Left.Defined {
+			d.PlotLeft = labels
+		}
+	} else {
+		d.PlotLeft = labels
+		d.PlotTop = 0
+		if t.vertical() {
+			d.PlotTop = t.Defaults.Margin + float64(t.Defaults.FontSize)
+		}
+		d.PlotRight = t.Config.ImageSize.WidthPx - t.Defaults.Margin
+		d.PlotBottom = t.Config.ImageSize.HeightPx
+		// make room for a legend that isn't below the chart
+		switch t.Config.Legend.Position {
+		case "right":
+			d.PlotRight -= legend.width + float64(t.Defaults.LabelBarGap)
+		case "top":
+			d.PlotTop += legend.height + t.Defaults.Margin
+			d.PlotBottom += legend.height + t.Defaults.Margin
+		}
+	}
+	d.TotalBarPixels = d.PlotRight - d.PlotLeft
+	if t.vertical() {
+		d.TotalBarPixels = d.PlotBottom - d.PlotTop
+	}
+}
+
+// vertical reports whether time runs up and down the chart instead of
+// across it
+func (t *Timeline) vertical() bool {
+	return t.Config.TimeAxis.Orientation == "vertical"
+}
+
+// timePixel is where a date goes along the time axis; that is the x
+// position for horizontal timelines, where time runs left to right,
+// and the y position for vertical ones, where it runs bottom to top.
+// TimeAxis order:reverse turns both of those around.
+func (t *Timeline) timePixel(date Date) float64 {
+	start := t.Config.Period.Start.coord()
+	frac := (date.coord() - start) / (t.Config.Period.End.coord() - start)
+	if t.Config.TimeAxis.Order == "reverse" {
+		frac = 1 - frac
+	}
+	if t.vertical() {
+		return t.Derived.PlotBottom - t.Derived.TotalBarPixels*frac
+	}
+	return t.Derived.PlotLeft + t.Derived.TotalBarPixels*frac
+}
+
+// barIncrement is the distance between the middles of two bars;
+// ImageSize barincrement: if there is one, otherwise enough for the
+// label with the most lines. Without a PlotArea the chart is only as
+// high as the bars, so a barincrement: that is too small for the
+// labels is widened to keep them apart and inside the image.
+func (t *Timeline) barIncrement() float64 {
+	labels := float64(t.Defaults.FontSize+t.Defaults.FontLeading) + float64(t.extraLabelLines())*t.labelLineHeight()
+	if t.Config.ImageSize.BarincrementPx > 0 {
+		if t.Config.PlotArea.Defined {
+			return t.Config.ImageSize.BarincrementPx
+		}
+		return max(t.Config.ImageSize.BarincrementPx, labels)
+	}
+	return labels
+}
+
+// extraLabelLines is how many more lines than one the longest bar
+// label has
+func (t *Timeline) extraLabelLines() int {
+	extra := 0
+	for _, row := range t.barRows() {
+		extra = max(extra, len(row.lines())-1)
+	}
+	return extra
+}
+
+// labelLineHeight is the distance between the lines of a bar label
+func (t *Timeline) labelLineHeight() float64 {
+	return t.labelFontSize()*4/3 + float64(t.Defaults.FontLeading)/2 // convert pts to pixels
+}
+
+// labelFontSize is the size of the bar labels in points:
+// Defaults.LabelFontSize if it is set, otherwise, with a PlotArea,
+// whose room for them was worked out for EasyTimeline's default size,
+// M, that size, and otherwise Defaults.FontSize. EasyTimeline draws at
+// 72dpi, so M is as many pixels as it is points.
+func (t *Timeline) labelFontSize() float64 {
+	if t.Defaults.LabelFontSize > 0 {
+		return float64(t.Defaults.LabelFontSize)
+	}
+	if t.Config.PlotArea.Defined {
+		size := t.points(FontSize{Keyword: "M"})
+		if gc := t.Defaults.GraphicsContext; gc != nil && gc.GetDPI() > 0 {
+			size *= 72 / float64(gc.GetDPI())
+		}
+		return size
+	}
+	return float64(t.Defaults.FontSize)
+}
+
+// barPixel is the middle of the i-th of n bars across the time axis;
+// bars are rows from the top of horizontal timelines and columns from
+// the left of vertical ones. AlignBars early packs them toward the
+// top (or left), late packs them toward the bottom (or right) and
+// justify spreads them over the whole plot area.
+func (t *Timeline) barPixel(i, n int) float64 {
+	first, last := t.Derived.PlotTop, t.Derived.PlotBottom
+	if t.vertical() {
+		first, last = t.Derived.PlotLeft, t.Derived.PlotRight
+	}
+	pitch := t.barIncrement()
+	switch t.Config.AlignBars {
+	case "justify":
+		if n == 1 {
+			return (first + last) / 2
+		}
+		return first + pitch/2 + float64(i)*(last-first-pitch)/float64(n-1)
+	case "late":
+		return last - (float64(n-i)-0.5)*pitch
+	default:
+		return first + (float64(i)+0.5)*pitch
+	}
+}
+
+// point turns a position along the time axis and one across it into
+// x and y
+func (t *Timeline) point(along, across float64) (x, y float64) {
+	if t.vertical() {
+		return across, along
+	}
+	return along, across
+}
+
+// barLabel is the text for a bar; bars that aren't in BarData are
+// labelled with their ID
+func (t *Timeline) barLabel(id string) string {
+	bar, ok := t.Bars[id]
+	if !ok || bar.Text == "" {
+		return id
+	}
+	return bar.Text
+}
+
+// barRow is one row (or, for vertical timelines, column) of the chart
+type barRow struct {
+	ID    string
+	Row   int    // the row in a barset; always 0 for other bars
+	Label string // empty for all but the first row of a barset
+}
+
+// lines are the lines of the label; "~" starts a new line, like it
+// does in TextData
+func (r barRow) lines() []string {
+	return strings.Split(r.Label, "~")
+}
+
+// barRows are the rows of the bars that have something on them, in
+// the order they are first used in PlotData; the extra rows of a
+// barset go straight after its first one
+func (t *Timeline) barRows() []barRow {
+	var rows []barRow
+	for _, item := range t.PlotItems {
+		row := barRow{ID: item.BarID, Row: item.BarsetRow}
+		if slices.ContainsFunc(rows, func(r barRow) bool { return r.ID == row.ID && r.Row == row.Row }) {
+			continue
+		}
+		if row.Row == 0 {
+			row.Label = t.barLabel(row.ID)
+		}
+		// after the last row of the same barset, if there is one
+		i := len(rows)
+		for j, r := range rows {
+			if r.ID == row.ID {
+				i = j + 1
+			}
+		}
+		rows = slices.Insert(rows, i, row)
+	}
+	return rows
+}
@@ -0,0 +1,173 @@
+package timeline
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestTimePixel(t *testing.T) {
+	raw := `DateFormat = yyyy
+Period = from:1900 till:2000
+TimeAxis = orientation:vertical order:reverse format:yyyy
+`
+	tl, err := ParseTimeline(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tl.Config.TimeAxis != (TimeAxis{Orientation: "vertical", Format: "yyyy", Order: "reverse"}) {
+		t.Errorf("time axis is %+v", tl.Config.TimeAxis)
+	}
+	tl.Derived = Derived{PlotLeft: 50, PlotRight: 450, PlotTop: 100, PlotBottom: 300, TotalBarPixels: 200}
+	// reversed, so the start of the period is at the top
+	if y := tl.timePixel(tl.Config.Period.Start); y != 100 {
+		t.Errorf("start is at %v", y)
+	}
+	if y := tl.timePixel(tl.Config.Period.End); y != 300 {
+		t.Errorf("end is at %v", y)
+	}
+	tl.Config.ImageSize.BarincrementPx = 100
+	if x := tl.barPixel(1, 4); x != 200 {
+		t.Errorf("second of four early bars is at %v", x)
+	}
+	tl.Config.AlignBars = "late"
+	if x := tl.barPixel(3, 4); x != 400 {
+		t.Errorf("last of four late bars is at %v", x)
+	}
+	tl.Config.AlignBars = "justify"
+	if x := tl.barPixel(2, 3); x != 400 {
+		t.Errorf("last of three justified bars is at %v", x)
+	}
+	if x, y := tl.point(10, 20); x != 20 || y != 10 {
+		t.Errorf("point is %v,%v", x, y)
+	}
+}
+
+func TestNumericDates(t *testing.T) {
+	raw := `DateFormat = x.y
+Period = from:-4600 till:-541
+PlotData =
+  bar:Eons from:-4000 till:end
+`
+	tl, err := ParseTimeline(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := tl.PlotItems[0]
+	if !item.From.Numeric || item.From.Value != -4000 || item.Til.Value != -541 {
+		t.Errorf("item is %+v", item)
+	}
+	tl.Derived = Derived{PlotLeft: 100, TotalBarPixels: 4059}
+	if x := tl.timePixel(item.From); x != 700 {
+		t.Errorf("-4000 is at %v", x)
+	}
+	tics := tl.tics(Scale{Increment: 1000})
+	if len(tics) != 5 || tics[0].label("") != "-4600" || tics[4].label("") != "-600" {
+		t.Errorf("tics are %+v", tics)
+	}
+	if tics = tl.tics(Scale{Increment: 2000, Start: NumericDate(-4000)}); len(tics) != 2 || tics[1].label("") != "-2000" {
+		t.Errorf("tics from -4000 are %+v", tics)
+	}
+
+	// the number, not the zero time that is also in a numeric date
+	if b, err := json.Marshal(item.From); err != nil || string(b) != "-4000" || item.From.String() != "-4000" {
+		t.Errorf("-4000 is written as %s (%v) and %s", b, err, item.From)
+	}
+	var d Date
+	if err := json.Unmarshal([]byte("-4000"), &d); err != nil || d != item.From {
+		t.Errorf("-4000 is read as %+v (%v)", d, err)
+	}
+
+	if _, err := ParseTimeline(context.Background(), "DateFormat = x.y\nPeriod = from:-46x till:0\n"); err == nil {
+		t.Error("expected an error for a date that isn't a number")
+	}
+}
+
+func TestMonthTics(t *testing.T) {
+	raw := `DateFormat = dd/mm/yyyy
+Period = from:15/02/1979 till:10/06/1979
+ScaleMajor = unit:month increment:2 start:01/03/1979
+ScaleMinor = unit:day increment:7 format:dd/mm
+`
+	tl, err := ParseTimeline(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	major := tl.Config.ScaleMajor
+	var labels []string
+	for _, d := range tl.tics(major) {
+		labels = append(labels, d.label(tl.ticFormat(major)))
+	}
+	if strings.Join(labels, ",") != "Mar 1979,May 1979" {
+		t.Errorf("major tics are %v", labels)
+	}
+	minor := tl.Config.ScaleMinor
+	tics := tl.tics(minor)
+	if len(tics) != 17 || tics[0].label(tl.ticFormat(minor)) != "15/02" || tics[1].label(tl.ticFormat(minor)) != "22/02" {
+		t.Errorf("minor tics are %v", tics)
+	}
+	if tics := tl.tics(Scale{Increment: 1}); len(tics) != 0 {
+		t.Errorf("expected no yearly tics in 1979 after 15 February, got %v", tics)
+	}
+
+	if _, err := ParseTimeline(context.Background(), "ScaleMajor = unit:week increment:1\n"); err == nil {
+		t.Error("expected an error for an unknown unit")
+	}
+}
+
+func TestEarlyDates(t *testing.T) {
+	raw := `DateFormat = yyyy
+Period = from:1650 till:1700
+PlotData =
+  bar:King from:1660 till:1690
+`
+	tl, err := ParseTimeline(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tl.Derived = Derived{PlotLeft: 0, TotalBarPixels: 500}
+	item := tl.PlotItems[0]
+	// a year is a little more or less than 10px, with leap years
+	if x := tl.timePixel(item.From); math.Abs(x-100) > 1 {
+		t.Errorf("1660 is at %v", x)
+	}
+	if x := tl.timePixel(item.Til); math.Abs(x-400) > 1 {
+		t.Errorf("1690 is at %v", x)
+	}
+}
+
+func TestMultiLineBarLabels(t *testing.T) {
+	raw := `DateFormat = yyyy
+Period = from:1970 till:2000
+BarData =
+  bar:A text:"Alpha~(1970-1980)"
+PlotData =
+  bar:A from:1970 till:1980
+  bar:B from:1980 till:1990
+`
+	tl, err := ParseTimeline(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tl.Defaults.FontSize, tl.Defaults.FontLeading = 12, 8
+	rows := tl.barRows()
+	if lines := rows[0].lines(); len(lines) != 2 || lines[1] != "(1970-1980)" {
+		t.Errorf("lines are %q", lines)
+	}
+	// one line of text is 20px, and the second line needs 20px more
+	if inc := tl.barIncrement(); inc != 40 {
+		t.Errorf("bar increment is %v", inc)
+	}
+	// a barincrement that is too small for the labels is widened
+	// without a PlotArea, but the PlotArea's is kept
+	tl.Config.ImageSize.BarincrementPx = 25
+	if inc := tl.barIncrement(); inc != 40 {
+		t.Errorf("bar increment with barincrement:25 is %v", inc)
+	}
+	tl.Config.PlotArea.Defined = true
+	if inc := tl.barIncrement(); inc != 25 {
+		t.Errorf("bar increment with barincrement:25 and a PlotArea is %v", inc)
+	}
+}
@@ -0,0 +1,139 @@
+package timeline
+
+import (
+	"math"
+	"slices"
+)
+
+// legendLayout is the size and arrangement of the legend, worked out
+// before anything is drawn so that the chart can make room for it
+type legendLayout struct {
+	items     []Color
+	colWidth  float64
+	rowHeight float64
+	perColumn int
+	width     float64
+	height    float64
+}
+
+// legendItems are the colors that go into the legend, in the order
+// they are first used in the chart
+func (t *Timeline) legendItems() []Color {
+	var ids []string
+	for _, plotItem := range t.PlotItems {
+		// don't add it to the list more than once
+		if slices.Contains(ids, plotItem.ColorID) {
+			continue
+		}
+		// PlotItems with Text don't need to go into the
+		// legend because they get the Text written on them in
+		// the chart
+		if plotItem.Text != "" {
+			continue
+		}
+		ids = append(ids, plotItem.ColorID)
+	}
+	// add the line events to the end of the legend
+	for _, lineEvent := range t.LineEvents {
+		if slices.Contains(ids, lineEvent.ColorID) {
+			continue
+		}
+		ids = append(ids, lineEvent.ColorID)
+	}
+
+	var items []Color
+	for _, id := range ids {
+		// colors without legend text aren't in the legend
+		if c, ok := t.Colors[id]; ok && c.Legend != "" {
+			items = append(items, c)
+		}
+	}
+	return items
+}
+
+// layoutLegend measures the legend; a vertical legend fills its
+// columns from top to bottom and a horizontal one is a single row. It
+// is written the same size as the bar labels, which with a PlotArea is
+// the size the room left for it was worked out for.
+func (t *Timeline) layoutLegend() legendLayout {
+	gc := t.Defaults.GraphicsContext
+	gc.SetFontSize(t.labelFontSize())
+	l := legendLayout{
+		items:     t.legendItems(),
+		rowHeight: t.labelFontSize()*4/3 + float64(t.Defaults.LabelBarGap),
+	}
+	if len(l.items) == 0 {
+		return l
+	}
+
+	if t.Config.Legend.ColumnWidth.Defined {
+		l.colWidth = t.Config.Legend.ColumnWidth.Pixels(t.Config.ImageSize.WidthPx)
+	} else {
+		for _, item := range l.items {
+			left, _, right, _ := gc.GetStringBounds(item.Legend)
+			textPos := right - left +
+				float64(t.Config.MaxLineWidth) +
+				float64(t.Defaults.LabelBarGap) +
+				float64(t.Defaults.LabelBarGap)
+			l.colWidth = max(l.colWidth, textPos)
+		}
+	}
+
+	if t.Config.Legend.Orientation == "horizontal" {
+		l.perColumn = 1
+	} else {
+		l.perColumn = int(math.Ceil(float64(len(l.items)) / float64(max(t.Config.Legend.Columns, 1))))
+	}
+	columns := int(math.Ceil(float64(len(l.items)) / float64(l.perColumn)))
+	l.width = float64(columns) * l.colWidth
+	l.height = float64(l.perColumn) * l.rowHeight
+	return l
+}
+
+// legendOrigin is the top left corner of the legend, where the Legend
+// command says it goes; belowAxis is the first free line under the
+// x-axis labels
+func (t *Timeline) legendOrigin(belowAxis, imageHeight float64) (x, y float64) {
+	gap := float64(t.Defaults.LabelBarGap)
+	x = t.Derived.PlotLeft - gap
+	y = belowAxis + gap
+	switch t.Config.Legend.Position {
+	case "top":
+		y = t.Defaults.Margin
+	case "right":
+		x = t.Derived.PlotRight + gap
+		y = t.Derived.PlotTop
+	}
+	if t.Config.Legend.Left.Defined {
+		x = t.Config.Legend.Left.Pixels(t.Config.ImageSize.WidthPx)
+	}
+	if t.Config.Legend.Top.Defined {
+		y = imageHeight - t.Config.Legend.Top.Pixels(imageHeight)
+	}
+	return x, y
+}
+
+// DrawLegend draws the legend where the Legend command says it goes;
+// belowAxis is the first free line under the x-axis labels
+func (t *Timeline) DrawLegend(l legendLayout, belowAxis, imageHeight float64) {
+	gc := t.Defaults.GraphicsContext
+	gap := float64(t.Defaults.LabelBarGap)
+	x, y := t.legendOrigin(belowAxis, imageHeight)
+
+	gc.SetFontSize(t.labelFontSize())
+	for i, item := range l.items {
+		itemX := x + float64(i/l.perColumn)*l.colWidth
+		itemY := y + (float64(i%l.perColumn)+0.5)*l.rowHeight
+		_, top, _, bottom := gc.GetStringBounds(item.Legend)
+		// draw little colored box for the legend (really a 13x13 line)
+		gc.SetStrokeColor(item.RGBA)
+		gc.SetLineWidth(float64(t.Config.MaxLineWidth))
+		gc.MoveTo(itemX+gap, itemY)
+		gc.LineTo(itemX+gap+float64(t.Config.MaxLineWidth), itemY)
+		gc.Stroke()
+		// write the legend text
+		gc.FillStringAt(item.Legend,
+			itemX+float64(t.Config.MaxLineWidth)+gap+gap,
+			itemY+(bottom-top)/2)
+	}
+}
@@ -0,0 +1,51 @@
+package timeline
+
+// acrossPixel converts a position across the time axis, measured from
+// the bottom (or, for vertical timelines, the left) of the image, to
+// pixels from the top left
+func (t *Timeline) acrossPixel(d Dimension, imageHeight float64) float64 {
+	if t.vertical() {
+		return d.Pixels(t.Config.ImageSize.WidthPx)
+	}
+	return imageHeight - d.Pixels(imageHeight)
+}
+
+// DrawLines draws the LineData lines on one layer, "back" for the
+// ones behind the bars or "front" for the ones over them
+func (t *Timeline) DrawLines(layer string, imageHeight float64) {
+	gc := t.Defaults.GraphicsContext
+	// a line at a date goes across the whole plot area unless it
+	// has a frompos or a tillpos
+	acrossStart, acrossEnd := t.Derived.PlotBottom, t.Derived.PlotTop
+	if t.vertical() {
+		acrossStart, acrossEnd = t.Derived.PlotLeft, t.Derived.PlotRight
+	}
+	for _, e := range t.LineEvents {
+		if e.Layer != layer {
+			continue
+		}
+		gc.SetLineWidth(e.Width)
+		gc.SetStrokeColor(t.color(e.ColorID))
+		switch e.Kind {
+		case "at":
+			from, till := acrossStart, acrossEnd
+			if e.FromPos.Defined {
+				from = t.acrossPixel(e.FromPos, imageHeight)
+			}
+			if e.TillPos.Defined {
+				till = t.acrossPixel(e.TillPos, imageHeight)
+			}
+			along := t.timePixel(e.Date)
+			gc.MoveTo(t.point(along, from))
+			gc.LineTo(t.point(along, till))
+		case "fromtill":
+			across := t.acrossPixel(e.AtPos, imageHeight)
+			gc.MoveTo(t.point(t.timePixel(e.From), across))
+			gc.LineTo(t.point(t.timePixel(e.Till), across))
+		case "points":
+			gc.MoveTo(e.Points[0].X, imageHeight-e.Points[0].Y)
+			gc.LineTo(e.Points[1].X, imageHeight-e.Points[1].Y)
+		}
+		gc.Stroke()
+	}
+}
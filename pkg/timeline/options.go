@@ -0,0 +1,78 @@
+package timeline
+
+import "time"
+
+// Option changes how a timeline is parsed or drawn
+type Option func(*options)
+
+type options struct {
+	fileName  string
+	startLine int
+	now       func() time.Time
+	location  *time.Location
+	mode      Mode
+}
+
+// Mode is how ParseTimeline deals with the problems in a timeline
+type Mode int
+
+const (
+	// Normal fails on data it can't use, like a bad date, and skips
+	// lines it can't read and things it doesn't support
+	Normal Mode = iota
+	// Lenient skips anything it can't read or use and still returns
+	// a timeline, with every problem in its Diagnostics
+	Lenient
+	// Strict fails on anything it doesn't understand or support
+	Strict
+)
+
+// WithFileName sets the file name used in the errors from ParseTimeline
+func WithFileName(name string) Option {
+	return func(o *options) {
+		o.fileName = name
+	}
+}
+
+// WithStartLine sets the line of the file that the timeline starts on,
+// for a timeline that is part of a bigger file, so that the lines in
+// the errors from ParseTimeline are lines of the whole file
+func WithStartLine(line int) Option {
+	return func(o *options) {
+		o.startLine = line
+	}
+}
+
+// WithClock sets the clock for "now" in {{#time}}, {{CURRENTYEAR}} and
+// the like, so that a timeline that runs up to today can be drawn the
+// same way again later
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// WithLocation sets the time zone that dates are read in and that the
+// tics are drawn for; the default is UTC so that a timeline looks the
+// same wherever it is drawn
+func WithLocation(loc *time.Location) Option {
+	return func(o *options) {
+		o.location = loc
+	}
+}
+
+// WithMode sets how ParseTimeline deals with problems; the default is
+// Normal
+func WithMode(mode Mode) Option {
+	return func(o *options) {
+		o.mode = mode
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{startLine: 1, now: time.Now, location: time.UTC}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
@@ -1,395 +1,1120 @@
-// from Google Gemini, which was crap
 // https://en.wikipedia.org/wiki/Help:EasyTimeline_syntax
-// so far, this does not implement everything
+// the grammar is in syntax.go; this file gives meaning to the
+// commands and attributes it finds
 package timeline
 
 import (
-	"bufio"
 	"context"
 	"errors"
 	"fmt"
-	"os"
+	"maps"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
-	"unicode"
-
-	"github.com/yuseferi/zax"
 )
 
-// --- Parsing Constants and Helper Regex ---
+// ParseTimeline parses the raw timeline configuration string into the
+// Timeline struct. If there are problems with the data, the error is a
+// ParseErrors with every one of them; which problems are errors and
+// which are only recorded in the Diagnostics of the timeline depends on
+// the Mode.
+func ParseTimeline(ctx context.Context, rawConfig string, opts ...Option) (*Timeline, error) {
+	t := &Timeline{
+		Colors: make(map[string]Color),
+		Bars:   make(map[string]Bar),
+	}
+
+	// Remove the surrounding MediaWiki tags and extra whitespace
+	rawConfig = strings.TrimPrefix(rawConfig, "{{#tag:timeline|\n")
+	rawConfig = strings.TrimSuffix(rawConfig, "\n}}")
+
+	statements, syntaxErrs := parseStatements(rawConfig)
+
+	t.Config.TimeAxis = TimeAxis{Orientation: "horizontal", Format: "yyyy", Order: "normal"}
+	t.Config.AlignBars = "early"
+	t.Config.PlotTextSize = FontSize{Points: 8}
+	t.Config.PlotTextColor = "black"
+	t.Config.Legend = Legend{Orientation: "vertical", Position: "bottom", Columns: 1}
+	p := &parser{
+		t:          t,
+		opts:       newOptions(opts),
+		dateLayout: "02/01/2006", // dd/mm/yyyy is the EasyTimeline default
+		defines:    make(map[string]*statement),
+	}
+	t.opts = p.opts
+	for _, err := range syntaxErrs {
+		p.skipped = append(p.skipped, &ParseError{
+			File:    p.opts.fileName,
+			Line:    err.Pos.Line + p.opts.startLine - 1,
+			Column:  err.Pos.Column,
+			Token:   err.Token,
+			Section: err.Section,
+			Msg:     err.Msg,
+		})
+	}
+	statements = p.applyPresets(statements)
+	p.expandDefines(statements)
+	p.evaluateParserFunctions(statements)
+	for _, s := range statements {
+		switch strings.ToLower(s.Name) {
+		case "imagesize":
+			p.parseImageSize(s)
+		case "plotarea":
+			p.parsePlotArea(s)
+		case "timeaxis":
+			p.parseTimeAxis(s)
+		case "alignbars":
+			p.parseAlignBars(s)
+		case "period":
+			p.parsePeriod(s)
+		case "dateformat":
+			p.parseDateFormat(s)
+		case "legend":
+			p.parseLegend(s)
+		case "scalemajor":
+			p.parseScale(s, &t.Config.ScaleMajor)
+		case "scaleminor":
+			p.parseScale(s, &t.Config.ScaleMinor)
+		case "colors":
+			p.parseColors(s)
+		case "backgroundcolors":
+			p.parseBackgroundColors(s)
+		case "bardata":
+			p.parseBarData(s)
+		case "plotdata":
+			p.parsePlotData(s)
+		case "linedata":
+			p.parseLineData(s)
+		case "textdata":
+			p.parseTextData(s)
+		case "define", "preset":
+			// already done
+		default:
+			p.ignoreStatement(s)
+		}
+	}
 
-// Regex to determine sections (lines that end in `=`)
-var sectionRe = regexp.MustCompile(`^[A-Z].*=$`)
+	switch p.opts.mode {
+	case Lenient:
+		t.Diagnostics = append(p.errs, p.skipped...)
+		t.Diagnostics.sortByPosition()
+		return t, nil
+	case Strict:
+		p.errs = append(p.errs, p.skipped...)
+		p.errs.sortByPosition()
+	default:
+		t.Diagnostics = p.skipped
+		t.Diagnostics.sortByPosition()
+	}
+	if len(p.errs) > 0 {
+		return nil, p.errs
+	}
+	return t, nil
+}
 
-// Regex for config lines (lines that have `=` but don't end with that
-var confirRe = regexp.MustCompile(`^[A-Z].*=.*`)
+// parser holds the state that carries from one command to the next
+type parser struct {
+	t          *Timeline
+	opts       options
+	dateLayout string
+	defines    map[string]*statement
+	errs       ParseErrors
+	skipped    ParseErrors // problems that are only errors in Strict mode
+	// the defaults for the lines of PlotData, see parsePlotData
+	plotDefaults PlotItem
+}
 
-// Regex to capture key-value pairs in the general config sections (like ImageSize).
-// var kvRe = regexp.MustCompile(`^\s*(\w+)\s*=\s*(.*)$`)
+// errorf records a problem with an attribute and carries on, so that
+// all of the problems in a file are reported at once
+func (p *parser) errorf(s *statement, a attribute, format string, args ...any) {
+	p.errs = append(p.errs, &ParseError{
+		File:    p.opts.fileName,
+		Line:    a.ValuePos.Line + p.opts.startLine - 1,
+		Column:  a.ValuePos.Column,
+		Token:   a.Value,
+		Section: s.Name,
+		Msg:     fmt.Sprintf(format, args...),
+	})
+}
 
-// Regex to capture BarData (e.g., bar:Alex text:Alex Kapranos)
-var barRe = regexp.MustCompile(`^\s*bar:(\w+).*text:(.+?)(?:\s+|$)$`)
+// ignoref records something in the file that isn't supported and so
+// was skipped, which is only an error in Strict mode
+func (p *parser) ignoref(s *statement, a attribute, format string, args ...any) {
+	p.skipped = append(p.skipped, &ParseError{
+		File:    p.opts.fileName,
+		Line:    a.ValuePos.Line + p.opts.startLine - 1,
+		Column:  a.ValuePos.Column,
+		Token:   a.Value,
+		Section: s.Name,
+		Msg:     fmt.Sprintf(format, args...),
+		Ignored: true,
+	})
+}
 
-// Regex to capture color definitions (e.g., id:lvocals value:red legend:Lead_vocals)
-// "legend" is optional; if it is not present, no entry will appear in the legend,
-// but the color is still important to have for other places
-// (this regex is similar to the 'plotRe' regex; refer to those notes)
-var colorRe = regexp.MustCompile(`\s*id:(\S+)\s+value:(\S+)\s*(?:legend:(.+))?`)
+// ignoreAttribute records an attribute that a command doesn't support,
+// e.g. "PlotData: shift: ignored"
+func (p *parser) ignoreAttribute(s *statement, a attribute) {
+	p.ignoref(s, attribute{Value: a.Value, ValuePos: a.Pos}, "%s: ignored", a.Key)
+}
 
-// widthRe for PlodData
-var widthRe = regexp.MustCompile(`.*width:\s*(\d+).*`)
+// ignoreStatement records a command that isn't supported, e.g.
+// "TimeAxis ignored"
+func (p *parser) ignoreStatement(s *statement) {
+	p.skipped = append(p.skipped, &ParseError{
+		File:    p.opts.fileName,
+		Line:    s.Pos.Line + p.opts.startLine - 1,
+		Column:  s.Pos.Column,
+		Msg:     s.Name + " ignored",
+		Ignored: true,
+	})
+}
 
-// plot config from the line in PlotData that looks like:
+// Preset = TimeHorizontal_AutoPlaceBars_UnitYear
 //
-//	align:center textcolor:white width:13 fontsize:8 shift:(6,-4)
-var fontColorRe = regexp.MustCompile(`.*textcolor:\s*(\w+).*`)
-var fontSizeRe = regexp.MustCompile(`.*fontsize:\s*(\d+).*`)
+// applyPresets puts the settings for any presets in front of the
+// statements from the file, so the file can change any of them
+func (p *parser) applyPresets(statements []*statement) []*statement {
+	var preset []*statement
+	for _, s := range statements {
+		if !strings.EqualFold(s.Name, "preset") {
+			continue
+		}
+		text, ok := presets[s.Value]
+		if !ok {
+			names := slices.Sorted(maps.Keys(presets))
+			p.errorf(s, attribute{Value: s.Value, ValuePos: s.ValuePos},
+				"unknown preset (must be one of %s)", strings.Join(names, ", "))
+			continue
+		}
+		presetStatements, _ := parseStatements(text)
+		preset = append(preset, presetStatements...)
+	}
+	return append(preset, statements...)
+}
 
-// Regex to capture PlotData and LineData (key:value pairs separated by spaces/tabs)
-// This is intentionally broad, refined in the parsing logic.
-// This matches lines like:
+// Define $now = 01/01/2025
 //
-//	bar:Name  from:25/01/1978  till:end  color:bs  text:Joy Division
-//
-// where "text:" is optional
-// in English it reads:
-//   - the line can start with 0 or more spaces
-//     (the real line always has leading spaces, but we might strip them off before we get here)
-//   - next is the word "bar" followed by a colon ("bar:"), then 1 or more of any "word character";
-//     the parens here mean "capture"
-//   - then expect 1 or more spaces
-//   - next is the string "from:" and 1 or more of any non-whitespace character which are captured
-//   - then expect 1 or more spaces
-//   - next is the string "till:" and 1 or more of any non-whitespace character which are captured
-//   - then expect 0 or more spaces (0 in case it is the end of the line)
-//   - then a non-capturing group with the string "text:",
-//     but do capture the next 1 or more non-whitespace characters;
-//     the whole outer group is optional (the trailing "?")
-//   - this results in 6 "matches":
-//     1. the whole line
-//     2. text after "bar:"
-//     3. string after "from:"
-//     4. string after "til:"
-//     5. string after "color:"
-//     6. (optinal) string after "text:"
-//     7. (optinal) string after "width:"
-var plotRe = regexp.MustCompile(`^\s*bar:(\w+)\s+from:(\S+)\s+till:(\S+)\s+color:(\S+)\s*(?:text:?(.+))?\s*(?:width:(\d+))?`)
-
-// replace spaces, including unicode spaces
-var replaceSpacesRe = regexp.MustCompile(`\p{Zs}`)
-
-// get things out of the ScaleM* lines
-var legendIncrementRe = regexp.MustCompile(`.*\s+increment:\s*(\d+).*`)
-var legendStartRe = regexp.MustCompile(`.*\s+start:\s*(\d+).*`)
-var legendColumnsRe = regexp.MustCompile(`.*\s+columns:\s*(\d+).*`)
-
-// ParseTimeline parses the raw timeline configuration string into the Timeline struct.
-func ParseTimeline(ctx context.Context, rawConfig string) (*Timeline, error) {
-	logger := zax.Get(ctx)
-	t := &Timeline{
-		Colors: make(map[string]Color),
-		Bars:   make(map[string]Bar),
+// expandDefines replaces every use of a constant, like till:$now, with
+// its value; constants can be used anywhere in the file, even before
+// they are defined, and can be defined in terms of other constants
+func (p *parser) expandDefines(statements []*statement) {
+	for _, s := range statements {
+		if s.Constant != "" {
+			p.defines[s.Constant] = s
+		}
+	}
+	for _, s := range statements {
+		if s.Constant != "" {
+			continue
+		}
+		if s.Value != "" {
+			s.Value = p.expand(s, attribute{Value: s.Value, ValuePos: s.ValuePos}, s.Value, nil)
+		}
+		for _, attrs := range s.Lines {
+			for i := range attrs {
+				attrs[i].Value = p.expand(s, attrs[i], attrs[i].Value, nil)
+			}
+		}
 	}
+}
 
-	// Remove the surrounding MediaWiki tags and extra whitespace
-	rawConfig = strings.TrimPrefix(rawConfig, "{{#tag:timeline|\n")
-	rawConfig = strings.TrimSuffix(rawConfig, "\n}}")
+// Regex for a constant (e.g., `$now`); the name starts with a letter,
+// so amounts like "$5" in text are left alone
+var constantRe = regexp.MustCompile(`\$[A-Za-z_]\w*`)
 
-	scanner := bufio.NewScanner(strings.NewReader(rawConfig))
-	currentSection := ""
-	currentWidth := 0
-	lineColor := ""
-	var dateLayout string
-
-	for scanner.Scan() {
-		line := strings.TrimRightFunc(scanner.Text(), unicode.IsSpace)
-		line = replaceSpacesRe.ReplaceAllString(line, " ")
-		if line == "" || strings.HasPrefix(line, "%") {
-			continue // Skip empty lines and comments
-		}
-
-		if (currentSection == "" || currentSection != "Config") &&
-			confirRe.MatchString(line) {
-			currentSection = "Config"
-		}
-
-		if sectionRe.MatchString(line) {
-			currentSection = strings.TrimRight(line, " =")
-		}
-
-		switch currentSection {
-		case "Config":
-			if strings.HasPrefix(line, "Period") {
-				var start, end time.Time
-				var err error
-				// Example: Period = from:01/07/2001 till:{{#time:d/m/Y}}
-
-				// first, deal with "Period=" vs "Period ="
-				line = strings.Replace(line, "=", " ", 1)
-				// split on spaces
-				parts := strings.Fields(line)
-
-				if len(parts) == 3 {
-					fromPart := strings.TrimSpace(
-						strings.Replace(parts[1], "from:", "", 1))
-					tillPart := strings.TrimSpace(
-						strings.Replace(parts[2], "till:", "", 1))
-					// see if there is some
-					// embedded code and hope it
-					// is just the current time
-					if strings.HasPrefix(tillPart, "{") {
-						end, err = parseTimeEmbed(tillPart)
-						if err != nil {
-							logger.Sugar().Fatalf(err.Error())
-						}
-						tillPart = end.Format(dateLayout)
-					}
-					start, err = time.Parse(dateLayout, fromPart)
-					if err == nil {
-						t.Config.Period.Start = start
-					} else {
-						logger.Sugar().Fatalf("couldn't compute start date; check format and data file")
+// expand replaces the constants in the value of a; seen is the chain
+// of constants being expanded, to catch a constant that refers to itself
+func (p *parser) expand(s *statement, a attribute, value string, seen []string) string {
+	return constantRe.ReplaceAllStringFunc(value, func(name string) string {
+		if slices.Contains(seen, name) {
+			p.errorf(s, a, "constant %s has a recursive definition (%s)",
+				name, strings.Join(append(seen, name), " -> "))
+			return ""
+		}
+		define, ok := p.defines[name]
+		if !ok {
+			p.errorf(s, a, "constant %s is not defined", name)
+			return ""
+		}
+		return p.expand(s, a, define.Value, append(seen, name))
+	})
+}
 
-					}
+// ImageSize = width:945 height:auto barincrement:20
+func (p *parser) parseImageSize(s *statement) {
+	for _, attrs := range s.Lines {
+		for _, a := range attrs {
+			switch a.Key {
+			case "width":
+				p.t.Config.ImageSize.WidthPx = p.pixels(s, a)
+			case "height":
+				p.t.Config.ImageSize.HeightPx = p.pixels(s, a)
+			case "barincrement":
+				p.t.Config.ImageSize.BarincrementPx = p.pixels(s, a)
+			default:
+				p.ignoreAttribute(s, a)
+			}
+		}
+	}
+}
 
-					end, err = time.Parse(dateLayout, tillPart)
-					if err == nil {
-						t.Config.Period.End = end
-					} else {
-						logger.Sugar().Fatalf("couldn't compute end date; check format and data file")
-					}
+// PlotArea = left:95 bottom:100 top:0 right:15
+// PlotArea = left:10% bottom:20% width:80% height:70%
+func (p *parser) parsePlotArea(s *statement) {
+	area := &p.t.Config.PlotArea
+	area.Defined = true
+	for _, attrs := range s.Lines {
+		for _, a := range attrs {
+			switch a.Key {
+			case "left":
+				area.Left = p.dimension(s, a)
+			case "right":
+				area.Right = p.dimension(s, a)
+			case "top":
+				area.Top = p.dimension(s, a)
+			case "bottom":
+				area.Bottom = p.dimension(s, a)
+			case "width":
+				area.Width = p.dimension(s, a)
+			case "height":
+				area.Height = p.dimension(s, a)
+			default:
+				p.ignoreAttribute(s, a)
+			}
+		}
+	}
+}
 
+// TimeAxis = orientation:vertical format:yyyy order:reverse
+func (p *parser) parseTimeAxis(s *statement) {
+	for _, attrs := range s.Lines {
+		for _, a := range attrs {
+			switch a.Key {
+			case "orientation":
+				switch a.Value {
+				case "horizontal", "vertical":
+					p.t.Config.TimeAxis.Orientation = a.Value
+				default:
+					p.errorf(s, a, "orientation must be horizontal or vertical")
 				}
+			case "format":
+				p.t.Config.TimeAxis.Format = a.Value
+			case "order":
+				switch a.Value {
+				case "normal", "reverse":
+					p.t.Config.TimeAxis.Order = a.Value
+				default:
+					p.errorf(s, a, "order must be normal or reverse")
+				}
+			default:
+				p.ignoreAttribute(s, a)
 			}
-			if strings.HasPrefix(line, "ImageSize") {
-				line = strings.ReplaceAll(line, "ImageSize = ", "")
-				for p := range strings.SplitSeq(line, " ") {
-					kv := strings.Split(p, ":")
-					switch kv[0] {
-					case "width":
-						if kv[1] == "auto" {
-							t.Config.ImageSize.WidthPx = 0 // 0 can mean unspec'd
-						} else {
-							w, _ := strconv.Atoi(kv[1])
-							t.Config.ImageSize.WidthPx = float64(w)
-						}
-					case "height":
-						if kv[1] == "auto" {
-							t.Config.ImageSize.HeightPx = 0
-						} else {
-							h, _ := strconv.Atoi(kv[1])
-							t.Config.ImageSize.HeightPx = float64(h)
-						}
-					case "barincrement":
-						if kv[1] == "auto" {
-							t.Config.ImageSize.BarincrementPx = 0
-						} else {
-							b, _ := strconv.Atoi(kv[1])
-							t.Config.ImageSize.BarincrementPx = float64(b)
-						}
-					}
+		}
+	}
+}
+
+// AlignBars = justify
+func (p *parser) parseAlignBars(s *statement) {
+	switch s.Value {
+	case "early", "late", "justify":
+		p.t.Config.AlignBars = s.Value
+	default:
+		p.errorf(s, attribute{Value: s.Value, ValuePos: s.ValuePos},
+			"AlignBars must be early, late or justify")
+	}
+}
+
+// Period = from:01/07/2001 till:{{#time:d/m/Y}}
+func (p *parser) parsePeriod(s *statement) {
+	for _, attrs := range s.Lines {
+		for _, a := range attrs {
+			switch a.Key {
+			case "from":
+				start, err := p.date(a.Value)
+				if err != nil {
+					p.errorf(s, a, "couldn't compute start date; check format and data file")
+					continue
 				}
+				p.t.Config.Period.From = a.Value
+				p.t.Config.Period.Start = start
+			case "till":
+				end, err := p.date(a.Value)
+				if err != nil {
+					p.errorf(s, a, "couldn't compute end date; check format and data file")
+					continue
+				}
+				p.t.Config.Period.To = a.Value
+				p.t.Config.Period.End = end
+			default:
+				p.ignoreAttribute(s, a)
 			}
-			if strings.HasPrefix(line, "DateFormat") {
-				dateLayout = "02/01/2006" // dd/mm/yyyy
-				dateFormat := strings.TrimSpace(strings.Split(line, "=")[1])
-				switch dateFormat {
-				case "mm/dd/yyyy":
-					dateLayout = "01/02/2006" // mm/dd/yyyy
-				case "yyyy":
-					dateLayout = "2006" // yyyy
+		}
+	}
+}
+
+// DateFormat = dd/mm/yyyy
+func (p *parser) parseDateFormat(s *statement) {
+	switch s.Value {
+	case "mm/dd/yyyy":
+		p.dateLayout = "01/02/2006" // mm/dd/yyyy
+	case "yyyy":
+		p.dateLayout = "2006" // yyyy
+	case numericDateFormat:
+		p.dateLayout = numericDateFormat // plain numbers, like -4600
+	default:
+		if s.Value != "dd/mm/yyyy" {
+			p.ignoref(s, attribute{Value: s.Value, ValuePos: s.ValuePos}, "%q ignored, using dd/mm/yyyy", s.Value)
+		}
+		p.dateLayout = "02/01/2006" // dd/mm/yyyy
+	}
+	p.t.Config.DateFormat = p.dateLayout
+}
+
+// Legend = orientation:vertical position:bottom columns:4
+// Legend = orientation:horizontal left:50 top:40 columnwidth:120
+func (p *parser) parseLegend(s *statement) {
+	legend := &p.t.Config.Legend
+	for _, attrs := range s.Lines {
+		for _, a := range attrs {
+			switch a.Key {
+			case "orientation":
+				switch a.Value {
+				case "horizontal", "vertical":
+					legend.Orientation = a.Value
 				default:
-					dateLayout = "02/01/2006" // dd/mm/yyyy
+					p.errorf(s, a, "orientation must be horizontal or vertical")
 				}
-				t.Config.DateFormat = dateLayout
-			}
-			if strings.HasPrefix(line, "Legend") {
-				var err error
-				matches := legendColumnsRe.FindStringSubmatch(line)
-				if len(matches) == 2 {
-					t.Config.LegendColumns, err = strconv.Atoi(matches[1])
-					if err != nil {
-						logger.Sugar().Fatalf("couldn't read legend columns (\"%s\" is not an integer)",
-							matches[1])
-					}
+			case "position":
+				switch a.Value {
+				case "top", "bottom", "right":
+					legend.Position = a.Value
+				default:
+					p.errorf(s, a, "position must be top, bottom or right")
 				}
+			case "columns":
+				columns, err := strconv.Atoi(a.Value)
+				if err != nil || columns < 1 {
+					p.errorf(s, a, "couldn't read legend columns (not a positive integer)")
+					continue
+				}
+				legend.Columns = columns
+			case "columnwidth":
+				legend.ColumnWidth = p.dimension(s, a)
+			case "left":
+				legend.Left = p.dimension(s, a)
+			case "top":
+				legend.Top = p.dimension(s, a)
+			default:
+				p.ignoreAttribute(s, a)
 			}
-			if strings.HasPrefix(line, "ScaleMajor") {
-				var err error
-				matches := legendIncrementRe.FindStringSubmatch(line)
-				if len(matches) == 2 {
-					t.Config.ScaleMajor.Increment, err = strconv.Atoi(matches[1])
-					if err != nil {
-						logger.Sugar().Fatalf("couldn't read legend major scale (\"%s\" is not an integer)",
-							matches[1])
-					}
+		}
+	}
+}
+
+// ScaleMajor = increment:5 start:1980
+// ScaleMinor = unit:month increment:1 start:01/03/1979 format:mmm gridcolor:lightgray
+func (p *parser) parseScale(s *statement, scale *Scale) {
+	for _, attrs := range s.Lines {
+		for _, a := range attrs {
+			switch a.Key {
+			case "increment":
+				increment, err := strconv.Atoi(a.Value)
+				if err != nil {
+					p.errorf(s, a, "couldn't read the increment (not an integer)")
+					continue
 				}
-				matches = legendStartRe.FindStringSubmatch(line)
-				if len(matches) == 2 {
-					t.Config.ScaleMajor.Start, err = strconv.Atoi(matches[1])
-					if err != nil {
-						logger.Sugar().Fatalf("couldn't read legend major start year (\"%s\" is not an integer)",
-							matches[1])
-					}
+				scale.Increment = increment
+			case "unit":
+				unit := strings.ToLower(a.Value)
+				if !slices.Contains([]string{"year", "month", "day"}, unit) {
+					p.errorf(s, a, "unit %q must be year, month or day", a.Value)
+					continue
 				}
-			}
-			if strings.HasPrefix(line, "ScaleMinor") {
-				var err error
-				matches := legendIncrementRe.FindStringSubmatch(line)
-				t.Config.ScaleMinor.Increment, err = strconv.Atoi(matches[1])
+				scale.Unit = unit
+			case "start":
+				// a year on its own is fine whatever the DateFormat
+				if year, err := strconv.Atoi(a.Value); err == nil && p.dateLayout != numericDateFormat {
+					scale.Start = CalendarDate(time.Date(year, time.January, 1, 0, 0, 0, 0, p.opts.location))
+					continue
+				}
+				start, err := p.date(a.Value)
 				if err != nil {
-					if err != nil {
-						logger.Sugar().Fatalf("couldn't read legend minor scale (\"%s\" is not an integer)",
-							matches[1])
-					}
+					p.errorf(s, a, "couldn't read the start (not a year or a date)")
+					continue
 				}
-				matches = legendStartRe.FindStringSubmatch(line)
-				if len(matches) == 2 {
-					t.Config.ScaleMinor.Start, err = strconv.Atoi(matches[1])
-					if err != nil {
-						logger.Sugar().Fatalf("couldn't read legend major start year (\"%s\" is not an integer)",
-							matches[1])
-					}
+				scale.Start = start
+			case "format":
+				scale.Format = a.Value
+			case "gridcolor":
+				if p.checkColor(s, a, a.Value) {
+					scale.GridColor = a.Value
 				}
+			default:
+				p.ignoreAttribute(s, a)
 			}
+		}
+	}
+}
 
-		case "Colors":
-			matches := colorRe.FindStringSubmatch(line)
-			if len(matches) == 4 {
-				colorID := strings.TrimSpace(matches[1])
-				t.Colors[colorID] = Color{
-					ID:     colorID,
-					Value:  strings.TrimSpace(matches[2]),
-					Legend: strings.TrimSpace(strings.ReplaceAll(matches[3], "_", " ")),
+// Colors =
+//
+//	id:lvocals value:red legend:Lead_vocals
+//
+// "legend" is optional; if it is not present, no entry will appear in
+// the legend, but the color is still important to have for other places
+func (p *parser) parseColors(s *statement) {
+	for _, attrs := range s.Lines {
+		var c Color
+		for _, a := range attrs {
+			switch a.Key {
+			case "id":
+				c.ID = a.Value
+			case "value":
+				rgba, err := ParseColor(a.Value)
+				if err != nil {
+					p.errorf(s, a, "%s", err.Error())
+					continue
 				}
+				c.Value = a.Value
+				c.RGBA = rgba
+			case "legend":
+				c.Legend = strings.ReplaceAll(a.Value, "_", " ")
+			default:
+				p.ignoreAttribute(s, a)
 			}
+		}
+		if c.ID != "" && c.Value != "" {
+			p.t.Colors[c.ID] = c
+		}
+	}
+}
 
-		case "BarData":
-			matches := barRe.FindStringSubmatch(line)
-			if len(matches) == 3 {
-				barID := matches[1]
-				t.Bars[barID] = Bar{
-					ID:   barID,
-					Text: matches[2],
+// BackgroundColors = canvas:sky bars:bars back:lightgray
+//
+// the values are IDs from Colors, so Colors has to come first
+func (p *parser) parseBackgroundColors(s *statement) {
+	for _, attrs := range s.Lines {
+		for _, a := range attrs {
+			var id *string
+			switch a.Key {
+			case "canvas":
+				id = &p.t.Config.BackgroundColors.Canvas
+			case "bars":
+				id = &p.t.Config.BackgroundColors.Bars
+			case "back":
+				id = &p.t.Config.BackgroundColors.Back
+			default:
+				p.ignoreAttribute(s, a)
+				continue
+			}
+			if _, ok := p.t.Colors[a.Value]; !ok {
+				p.errorf(s, a, "color is not defined in Colors")
+				continue
+			}
+			*id = a.Value
+		}
+	}
+}
+
+// BarData =
+//
+//	bar:Alex text:"Alex Kapranos" link:https://example.com/alex
+//	barset:Gigs text:Gigs
+func (p *parser) parseBarData(s *statement) {
+	for _, attrs := range s.Lines {
+		var b Bar
+		for _, a := range attrs {
+			switch a.Key {
+			case "bar":
+				b.ID = a.Value
+			case "barset":
+				b.ID = a.Value
+				b.Barset = true
+			case "text":
+				var link string
+				b.Text, link = wikiText(unquote(a.Value))
+				if b.Link == "" {
+					b.Link = link
 				}
+			case "link":
+				b.Link = a.Value
+			default:
+				p.ignoreAttribute(s, a)
 			}
+		}
+		if b.ID != "" {
+			p.t.Bars[b.ID] = b
+		}
+	}
+}
 
-		case "PlotData":
-			line = strings.TrimSpace(line)
-			// can't do this in regex; negative look-ahead isn't supported :/
-			if !strings.Contains(strings.ToLower(line), "bar") {
-				// get string width
-				matches := widthRe.FindStringSubmatch(line)
-				var err error
-				if len(matches) == 2 {
-					currentWidth, err = strconv.Atoi(matches[1])
+// PlotData =
+//
+//	width:13 textcolor:black align:left anchor:from shift:(11,-4)
+//	bar:Name  from:25/01/1978  till:end  color:bs  text:Joy Division
+//	bar:Name  at:18/05/1980  mark:(line,white)  text:Last gig
+//	barset:Gigs
+//	at:20/07/1979 text:Factory
+//	at:13/08/1979 text:Russell Club
+//
+// a line without from:, till: or at: sets the defaults for the lines
+// that follow it, including the bar they are drawn on; after a barset:
+// each line goes on the next row of the barset ("barset:break" goes
+// back to its first row and "barset:skip" leaves a row empty)
+func (p *parser) parsePlotData(s *statement) {
+	currentBar := ""
+	// after a barset: line each item goes on the next row of the
+	// barset, until a bar: line
+	currentBarset := ""
+	barsetRow := 0
+	// the color, text, mark and text placement on a line without
+	// from/till/at apply to the lines after it, even in a later
+	// PlotData, which is how a Preset sets them
+	defaults := &p.plotDefaults
+	for _, attrs := range s.Lines {
+		if !hasKey(attrs, "from") && !hasKey(attrs, "till") && !hasKey(attrs, "at") {
+			for _, a := range attrs {
+				switch a.Key {
+				case "bar":
+					currentBar = a.Value
+					currentBarset = ""
+				case "barset":
+					switch strings.ToLower(a.Value) {
+					case "break":
+						barsetRow = 0
+					case "skip":
+						barsetRow++
+					default:
+						currentBarset = a.Value
+						barsetRow = 0
+					}
+				case "color":
+					if p.checkColor(s, a, a.Value) {
+						defaults.ColorID = a.Value
+					}
+				case "text":
+					var link string
+					defaults.Text, link = wikiText(unquote(a.Value))
+					// a link: on the line wins, wherever it is
+					if !hasKey(attrs, "link") {
+						defaults.Link = link
+					}
+				case "link":
+					defaults.Link = a.Value
+				case "mark":
+					mark, err := parseMark(a.Value)
 					if err != nil {
-						fmt.Printf("couldn't parse width: %s", err.Error())
-						os.Exit(1)
+						p.errorf(s, a, "%s", err.Error())
+						continue
 					}
-					t.Config.DefaultLineWidth = currentWidth
-					if t.Config.DefaultLineWidth > t.Config.MaxLineWidth {
-						t.Config.MaxLineWidth = t.Config.DefaultLineWidth
+					if p.checkColor(s, a, mark.ColorID) {
+						defaults.Mark = mark
 					}
-				}
-				// get fontsize for bar labels
-				matches = fontSizeRe.FindStringSubmatch(line)
-				if len(matches) == 2 {
-					fontsize, err := strconv.Atoi(matches[1])
-					t.Config.PlotTextSize = 12 // default to 12pt font
+				case "align", "anchor", "shift":
+					if err := parseTextPlacement(defaults, a); err != nil {
+						p.errorf(s, a, "%s", err.Error())
+					}
+				case "width":
+					width, err := strconv.Atoi(a.Value)
+					if err != nil {
+						p.errorf(s, a, "couldn't parse width (not an integer)")
+						continue
+					}
+					p.t.Config.DefaultLineWidth = width
+					if p.t.Config.DefaultLineWidth > p.t.Config.MaxLineWidth {
+						p.t.Config.MaxLineWidth = p.t.Config.DefaultLineWidth
+					}
+				case "fontsize":
+					p.t.Config.PlotTextSize = FontSize{Points: 12} // default to 12pt font
+					fontsize, err := parseFontSize(a.Value)
 					if err != nil {
-						logger.Error("Couldn't get fontsize from config file")
+						p.errorf(s, a, "%s", err.Error())
 					} else {
-						t.Config.PlotTextSize = fontsize
+						p.t.Config.PlotTextSize = fontsize
 					}
-				}
-				// get color for bar labels
-				matches = fontColorRe.FindStringSubmatch(line)
-				if len(matches) == 2 {
-					t.Config.PlotTextColor = "white" // default to white
-					if matches[1] != "" {
-						t.Config.PlotTextColor = matches[1]
+				case "textcolor":
+					p.t.Config.PlotTextColor = "white" // default to white
+					if a.Value != "" && p.checkColor(s, a, a.Value) {
+						p.t.Config.PlotTextColor = a.Value
 					}
+				default:
+					p.ignoreAttribute(s, a)
 				}
-
 			}
+			continue
+		}
 
-			// Parse plot item using the last known width
-			matches := plotRe.FindStringSubmatch(line)
-			var from, til time.Time
+		item := *defaults
+		item.BarID = currentBar
+		if currentBarset != "" && !hasKey(attrs, "bar") {
+			item.BarID = currentBarset
+			item.BarsetRow = barsetRow
+			barsetRow++
+		}
+		item.Width = p.t.Config.DefaultLineWidth
+		ok := true
+		for _, a := range attrs {
 			var err error
-			if len(matches) == 7 {
-				width := t.Config.DefaultLineWidth
-				w, _ := strconv.Atoi(matches[6])
-				if w != 0 {
-					width = w
-				}
-				if matches[2] == "start" {
-					from = t.Config.Period.Start
-				} else {
-					from, err = time.Parse(dateLayout, matches[2])
-					if err != nil {
-						logger.Sugar().Fatalf("couldn't read the start date (\"%s\" is not a date)",
-							matches[1])
-					}
+			switch a.Key {
+			case "bar":
+				item.BarID = a.Value
+			case "at":
+				item.At, err = p.date(a.Value)
+				if err != nil {
+					p.errorf(s, a, "couldn't read the date (not a date)")
+					ok = false
 				}
-				if matches[3] == "end" {
-					til = t.Config.Period.End
-				} else {
-					til, err = time.Parse(dateLayout, matches[3])
-					if err != nil {
-						logger.Sugar().Fatalf("couldn't read the til date (\"%s\" is not a date)",
-							matches[1])
-					}
+				item.Point = true
+			case "mark":
+				item.Mark, err = parseMark(a.Value)
+				if err != nil {
+					p.errorf(s, a, "%s", err.Error())
+					ok = false
+				} else if !p.checkColor(s, a, item.Mark.ColorID) {
+					ok = false
+				}
+			case "from":
+				item.From, err = p.date(a.Value)
+				if err != nil {
+					p.errorf(s, a, "couldn't read the start date (not a date)")
+					ok = false
+				}
+			case "till":
+				item.Til, err = p.date(a.Value)
+				if err != nil {
+					p.errorf(s, a, "couldn't read the til date (not a date)")
+					ok = false
+				}
+			case "color":
+				item.ColorID = a.Value
+				if !p.checkColor(s, a, a.Value) {
+					ok = false
 				}
-				if matches[6] == "" {
-					width = t.Config.DefaultLineWidth
-				}
-				t.PlotItems = append(t.PlotItems, PlotItem{
-					BarID:   matches[1],
-					From:    from,
-					Til:     til,
-					ColorID: matches[4],
-					Width:   width, // Use the last parsed width
-					Text:    matches[5],
-				})
+			case "width":
+				width, err := strconv.Atoi(a.Value)
+				if err != nil {
+					p.errorf(s, a, "couldn't parse width (not an integer)")
+					ok = false
+				}
+				item.Width = width
+			case "text":
+				var link string
+				item.Text, link = wikiText(unquote(a.Value))
+				// a link: on the line wins, wherever it is
+				if !hasKey(attrs, "link") {
+					item.Link = link
+				}
+			case "link":
+				item.Link = a.Value
+			case "align", "anchor", "shift":
+				if err := parseTextPlacement(&item, a); err != nil {
+					p.errorf(s, a, "%s", err.Error())
+					ok = false
+				}
+			case "fontsize":
+				item.FontSize, err = parseFontSize(a.Value)
+				if err != nil {
+					p.errorf(s, a, "%s", err.Error())
+					ok = false
+				}
+			case "textcolor":
+				item.TextColor = a.Value
+				if !p.checkColor(s, a, a.Value) {
+					ok = false
+				}
+			default:
+				p.ignoreAttribute(s, a)
 			}
+		}
+		if ok {
+			p.t.PlotItems = append(p.t.PlotItems, item)
+		}
+	}
+}
 
-		case "LineData":
-			cleanLine := strings.TrimSpace(line)
-			cleanLine = replaceSpacesRe.ReplaceAllString(cleanLine, " ")
-			if strings.HasPrefix(cleanLine, "color:") {
-				lineColor = strings.Split(cleanLine, ":")[1]
+// LineData =
+//
+//	layer:back width:0.5
+//	color:studio
+//	at:08/05/1979
+//	at:1985 frompos:40 tillpos:300
+//	from:1980 till:1990 atpos:50
+//	points:(100,40)(400,40)
+//
+// like PlotData, a line without at:, from:, till: or points: sets the
+// defaults for the lines that follow it
+func (p *parser) parseLineData(s *statement) {
+	defaults := LineEvents{Width: 2, Layer: "front"}
+	for _, attrs := range s.Lines {
+		event := defaults
+		switch {
+		case hasKey(attrs, "points"):
+			event.Kind = "points"
+		case hasKey(attrs, "at"):
+			event.Kind = "at"
+		case hasKey(attrs, "from"), hasKey(attrs, "till"):
+			event.Kind = "fromtill"
+			event.From = p.t.Config.Period.Start
+			event.Till = p.t.Config.Period.End
+		}
+		target := &event
+		if event.Kind == "" {
+			target = &defaults
+		}
+		ok := true
+		for _, a := range attrs {
+			var err error
+			switch a.Key {
+			case "color":
+				if !p.checkColor(s, a, a.Value) {
+					ok = false
+					continue
+				}
+				target.ColorID = a.Value
+			case "layer":
+				layer := strings.ToLower(a.Value)
+				if layer != "front" && layer != "back" {
+					p.errorf(s, a, "layer %q must be front or back", a.Value)
+					ok = false
+					continue
+				}
+				target.Layer = layer
+			case "width":
+				target.Width, err = strconv.ParseFloat(a.Value, 64)
+				if err != nil {
+					p.errorf(s, a, "couldn't parse width (not a number)")
+					ok = false
+				}
+			case "frompos":
+				target.FromPos = p.dimension(s, a)
+			case "tillpos":
+				target.TillPos = p.dimension(s, a)
+			case "atpos":
+				target.AtPos = p.dimension(s, a)
+			case "at":
+				target.Date, err = p.date(a.Value)
+				if err != nil {
+					p.errorf(s, a, "couldn't read the date (not a date)")
+					ok = false
+				}
+			case "from":
+				target.From, err = p.date(a.Value)
+				if err != nil {
+					p.errorf(s, a, "couldn't read the start date (not a date)")
+					ok = false
+				}
+			case "till":
+				target.Till, err = p.date(a.Value)
+				if err != nil {
+					p.errorf(s, a, "couldn't read the til date (not a date)")
+					ok = false
+				}
+			case "points":
+				target.Points, err = parsePoints(a.Value)
+				if err != nil {
+					p.errorf(s, a, "%s", err.Error())
+					ok = false
+				}
+			default:
+				p.ignoreAttribute(s, a)
 			}
-			if strings.HasPrefix(cleanLine, "at:") {
-				date := strings.Split(cleanLine, ":")[1]
-				d, err := time.Parse(dateLayout, date)
+		}
+		if event.Kind == "fromtill" && !event.AtPos.Defined {
+			p.errorf(s, attrs[0], "a line with from: and till: needs an atpos:")
+			ok = false
+		}
+		if event.Kind != "" && ok {
+			p.t.LineEvents = append(p.t.LineEvents, event)
+		}
+	}
+}
+
+// TextData =
+//
+//	pos:(20,40) fontsize:10 textcolor:black tabs:(60-left,140-right)
+//	text:"Name^Joined^Left"
+//	text:"Robert^1978^"
+//
+// like the other data sections, a line without text: sets the defaults
+// for the lines that follow it; text without a pos: goes on the line
+// under the text before it
+func (p *parser) parseTextData(s *statement) {
+	var current TextItem
+	hasPos := false
+	for _, attrs := range s.Lines {
+		for _, a := range attrs {
+			switch a.Key {
+			case "pos":
+				x, y, err := parsePair(a.Value)
 				if err != nil {
-					logger.Sugar().Fatalf("couldn't read the date in LineData (\"%s\" is not a date)", date)
+					p.errorf(s, a, "pos must be (x,y) in pixels")
+					continue
+				}
+				current.X, current.Y, current.Line = x, y, 0
+				hasPos = true
+			case "fontsize":
+				fontsize, err := parseFontSize(a.Value)
+				if err != nil {
+					p.errorf(s, a, "%s", err.Error())
+					continue
+				}
+				current.FontSize = fontsize
+			case "textcolor":
+				if p.checkColor(s, a, a.Value) {
+					current.TextColor = a.Value
 				}
-				t.LineEvents = append(t.LineEvents, LineEvents{
-					ColorID: lineColor,
-					Date:    d,
-				})
+			case "tabs":
+				tabs, err := parseTabs(a.Value)
+				if err != nil {
+					p.errorf(s, a, "%s", err.Error())
+					continue
+				}
+				current.Tabs = tabs
+			case "text":
+				// below, once the rest of the line is read
+			default:
+				p.ignoreAttribute(s, a)
+			}
+		}
+		for _, a := range attrs {
+			if a.Key != "text" {
+				continue
 			}
+			if !hasPos && len(p.t.TextItems) > 0 {
+				last := p.t.TextItems[len(p.t.TextItems)-1]
+				current.Line = last.Line + len(last.Lines())
+			}
+			hasPos = false
+			item := current
+			item.Text, item.Link = wikiText(strings.Trim(a.Value, "\""))
+			p.t.TextItems = append(p.t.TextItems, item)
 		}
 	}
+}
+
+// date reads a date in the current DateFormat; "start" and "end" are
+// the ends of the Period
+func (p *parser) date(value string) (Date, error) {
+	switch value {
+	case "start":
+		return p.t.Config.Period.Start, nil
+	case "end":
+		return p.t.Config.Period.End, nil
+	}
+	if p.dateLayout == numericDateFormat {
+		v, err := strconv.ParseFloat(value, 64)
+		return NumericDate(v), err
+	}
+	t, err := time.ParseInLocation(p.dateLayout, value, p.opts.location)
+	return CalendarDate(t), err
+}
+
+// pixels reads a size in pixels; "auto" is 0, which means unspecified
+func (p *parser) pixels(s *statement, a attribute) float64 {
+	if a.Value == "auto" {
+		return 0
+	}
+	v, err := strconv.Atoi(a.Value)
+	if err != nil {
+		p.errorf(s, a, "couldn't read %s (not a number of pixels or \"auto\")", a.Key)
+	}
+	return float64(v)
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading configuration: %w", err)
+// dimension reads a size like "95", "95px" or "10%"
+func (p *parser) dimension(s *statement, a attribute) Dimension {
+	value, percent := strings.CutSuffix(a.Value, "%")
+	value = strings.TrimSuffix(value, "px")
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		p.errorf(s, a, "couldn't read %s (not a number of pixels or a percentage)", a.Key)
+		return Dimension{}
 	}
+	return Dimension{Defined: true, Value: v, Percent: percent}
+}
 
-	return t, nil
+// parseFontSize reads a font size in points, like "10", or one of
+// the keywords XS, S, M, L and XL
+func parseFontSize(value string) (FontSize, error) {
+	keyword := strings.ToUpper(value)
+	if _, ok := DefaultFontSizes[keyword]; ok {
+		return FontSize{Keyword: keyword}, nil
+	}
+	points, err := strconv.Atoi(value)
+	if err != nil || points <= 0 {
+		return FontSize{}, errors.New("couldn't parse fontsize (not a number of points or XS, S, M, L or XL)")
+	}
+	return FontSize{Points: points}, nil
+}
+
+// wikiText replaces the wiki links in text, like
+// "[[Robert Smith (musician)|Robert Smith]]" or "[[Lol Tolhurst]]",
+// with the text that is shown for them; link is the page the first one
+// goes to
+func wikiText(text string) (plain, link string) {
+	var sb strings.Builder
+	for {
+		start := strings.Index(text, "[[")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(text[start:], "]]")
+		if end < 0 {
+			break
+		}
+		target, label, found := strings.Cut(text[start+2:start+end], "|")
+		if !found {
+			label = target
+		}
+		if link == "" {
+			link = strings.TrimSpace(target)
+		}
+		sb.WriteString(text[:start])
+		sb.WriteString(label)
+		text = text[start+end+2:]
+	}
+	sb.WriteString(text)
+	return sb.String(), link
+}
+
+// unquote removes the quotes from around a value like "Robert Smith"
+func unquote(value string) string {
+	if len(value) >= 2 && strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// checkColor reports whether id is the ID of a color in Colors or a
+// color value like "red" or "rgb(1,0,0)", and records an error if not
+func (p *parser) checkColor(s *statement, a attribute, id string) bool {
+	if _, ok := p.t.Colors[id]; ok {
+		return true
+	}
+	if _, err := ParseColor(id); err != nil {
+		p.errorf(s, a, "color %q is not defined in Colors or a color value", id)
+		return false
+	}
+	return true
+}
+
+// parseMark reads a mark like "(line,white)"; the color is optional
+// and is black if it isn't there
+func parseMark(value string) (Mark, error) {
+	inner, ok := strings.CutPrefix(value, "(")
+	inner, ok2 := strings.CutSuffix(inner, ")")
+	if !ok || !ok2 {
+		return Mark{}, errors.New("mark must be like (line,color)")
+	}
+	style, colorID, _ := strings.Cut(inner, ",")
+	style = strings.TrimSpace(style)
+	if style != "line" {
+		return Mark{}, fmt.Errorf("mark style %q must be line", style)
+	}
+	colorID = strings.TrimSpace(colorID)
+	if colorID == "" {
+		colorID = "black"
+	}
+	return Mark{Style: style, ColorID: colorID}, nil
+}
+
+// parseTextPlacement reads one of the align:, anchor: or shift:
+// attributes that place the text of a PlotItem
+func parseTextPlacement(item *PlotItem, a attribute) error {
+	switch a.Key {
+	case "align":
+		value := strings.ToLower(a.Value)
+		if !slices.Contains([]string{"left", "center", "right"}, value) {
+			return fmt.Errorf("align %q must be left, center or right", a.Value)
+		}
+		item.Align = value
+	case "anchor":
+		value := strings.ToLower(a.Value)
+		if !slices.Contains([]string{"from", "middle", "till"}, value) {
+			return fmt.Errorf("anchor %q must be from, middle or till", a.Value)
+		}
+		item.Anchor = value
+	case "shift":
+		x, y, err := parsePair(a.Value)
+		if err != nil {
+			return fmt.Errorf("shift must be like (6,-4): %v", err)
+		}
+		item.ShiftX, item.ShiftY = x, y
+	}
+	return nil
 }
 
-func parseTimeEmbed(t string) (time.Time, error) {
-	// we are looking for "{{#time:d/m/Y}}" but don't care about
-	// the format, since we are doing time the right way
-	if strings.HasPrefix(t, "{{#time:") {
-		return time.Now(), nil
-	} else {
-		return time.Time{}, errors.New("could not parse embedded command")
+// parsePoints reads the two ends of a line like "(100,40)(400,40)"
+func parsePoints(value string) ([]Point, error) {
+	var points []Point
+	for pair := range strings.SplitSeq(value, ")") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		x, y, err := parsePair(pair + ")")
+		if err != nil {
+			return nil, errors.New("points must be like (100,40)(400,40)")
+		}
+		points = append(points, Point{X: x, Y: y})
+	}
+	if len(points) != 2 {
+		return nil, fmt.Errorf("points needs 2 points, not %d", len(points))
+	}
+	return points, nil
+}
+
+// parsePair reads a pair of numbers like "(6,-4)"
+func parsePair(value string) (float64, float64, error) {
+	inner, ok := strings.CutPrefix(value, "(")
+	inner, ok2 := strings.CutSuffix(inner, ")")
+	first, second, ok3 := strings.Cut(inner, ",")
+	if !ok || !ok2 || !ok3 {
+		return 0, 0, errors.New("not a pair of numbers")
+	}
+	x, err := strconv.ParseFloat(strings.TrimSpace(first), 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	y, err := strconv.ParseFloat(strings.TrimSpace(second), 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return x, y, nil
+}
+
+// parseTabs reads tab stops like "(60-left,140-center,200-right)"
+func parseTabs(value string) ([]TabStop, error) {
+	inner, ok := strings.CutPrefix(value, "(")
+	inner, ok2 := strings.CutSuffix(inner, ")")
+	if !ok || !ok2 {
+		return nil, errors.New("tabs must be a list like (60-left,140-right)")
+	}
+	var tabs []TabStop
+	for tab := range strings.SplitSeq(inner, ",") {
+		pos, align, _ := strings.Cut(strings.TrimSpace(tab), "-")
+		if align == "" {
+			align = "left"
+		}
+		if align != "left" && align != "center" && align != "right" {
+			return nil, fmt.Errorf("tab alignment %q must be left, center or right", align)
+		}
+		p, err := strconv.ParseFloat(pos, 64)
+		if err != nil {
+			return nil, fmt.Errorf("tab position %q is not a number", pos)
+		}
+		tabs = append(tabs, TabStop{Pos: p, Align: align})
+	}
+	return tabs, nil
+}
+
+func hasKey(attrs []attribute, key string) bool {
+	for _, a := range attrs {
+		if a.Key == key {
+			return true
+		}
 	}
+	return false
 }
@@ -0,0 +1,625 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseExamples(t *testing.T) {
+	files, err := filepath.Glob("../../examples/*.data")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no example files found: %v", err)
+	}
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tl, err := ParseTimeline(context.Background(), string(raw))
+		if err != nil {
+			t.Errorf("%s: %v", file, err)
+			continue
+		}
+		if len(tl.Bars) == 0 || len(tl.PlotItems) == 0 || len(tl.Colors) == 0 {
+			t.Errorf("%s: parsed %d bars, %d plot items and %d colors",
+				file, len(tl.Bars), len(tl.PlotItems), len(tl.Colors))
+		}
+	}
+}
+
+func TestParseAttributeOrder(t *testing.T) {
+	raw := `DateFormat = yyyy
+Period = till:2000 from:1990
+
+Colors =
+  legend:Lead_vocals value:red id:lv
+
+BarData =
+  text:Robert Smith bar:Robert
+
+PlotData =
+  textcolor:black width:13
+  Bar:Robert text:Band name width:9 color:lv till:end from:1991
+`
+	tl, err := ParseTimeline(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tl.Config.Period.Start.Year() != 1990 || tl.Config.Period.End.Year() != 2000 {
+		t.Errorf("period is %v - %v", tl.Config.Period.Start, tl.Config.Period.End)
+	}
+	if c := tl.Colors["lv"]; c.Value != "red" || c.Legend != "Lead vocals" {
+		t.Errorf("color is %+v", c)
+	}
+	if b := tl.Bars["Robert"]; b.Text != "Robert Smith" {
+		t.Errorf("bar is %+v", b)
+	}
+	if len(tl.PlotItems) != 1 {
+		t.Fatalf("got %d plot items", len(tl.PlotItems))
+	}
+	item := tl.PlotItems[0]
+	if item.BarID != "Robert" || item.Text != "Band name" || item.Width != 9 ||
+		item.ColorID != "lv" || item.From.Year() != 1991 || item.Til.Year() != 2000 {
+		t.Errorf("plot item is %+v", item)
+	}
+	if tl.Config.PlotTextColor != "black" || tl.Config.DefaultLineWidth != 13 {
+		t.Errorf("plot defaults are %q and %d", tl.Config.PlotTextColor, tl.Config.DefaultLineWidth)
+	}
+}
+
+func TestParseStatements(t *testing.T) {
+	raw := `#> a block
+comment <#
+Period = from:01/01/1990 till:{{#time:d/m/Y}} # trailing comment
+PlotData=
+  bar:Name from:start till:end text:"Stiff: Kittens" shift:(6, -4)
+Colors =
+  id:blue value: #336699 # a value, then a comment
+`
+	statements, errs := parseStatements(raw)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(statements) != 3 {
+		t.Fatalf("got %d statements", len(statements))
+	}
+	period := statements[0].Lines[0]
+	if period[1].Value != "{{#time:d/m/Y}}" || period[1].Pos != (position{3, 26}) {
+		t.Errorf("till is %+v", period[1])
+	}
+	plot := statements[1].Lines[0]
+	if len(plot) != 5 || plot[3].Value != `"Stiff: Kittens"` || plot[4].Value != "(6, -4)" {
+		t.Errorf("plot line is %+v", plot)
+	}
+	if colors := statements[2].Lines[0]; len(colors) != 2 || colors[1].Value != "#336699" {
+		t.Errorf("colors line is %+v", colors)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	raw := `DateFormat = dd/mm/yyyy
+Period = from:01/01/1990 till:01/01/2000
+PlotData =
+  width:wide
+  bar:Name from:32/13/1990 till:end color:red
+  bar:Name from:start till:end color:red
+`
+	tl, err := ParseTimeline(context.Background(), raw, WithFileName("test.data"))
+	if tl != nil {
+		t.Errorf("expected no timeline, got %+v", tl)
+	}
+	var errs ParseErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected ParseErrors, got %T: %v", err, err)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %d: %v", len(errs), err)
+	}
+	want := ParseError{File: "test.data", Line: 5, Column: 17, Token: "32/13/1990", Section: "PlotData"}
+	got := *errs[1]
+	got.Msg = ""
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if errs[0].Line != 4 || errs[0].Token != "wide" {
+		t.Errorf("first error is %+v", errs[0])
+	}
+}
+
+func TestParsePlotArea(t *testing.T) {
+	raw := "PlotArea = left:10% bottom:100 width:80% top:5px\n"
+	tl, err := ParseTimeline(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	area := tl.Config.PlotArea
+	if !area.Defined || area.Right.Defined || area.Height.Defined {
+		t.Errorf("plot area is %+v", area)
+	}
+	left, top, right, bottom := area.Rect(800, 400)
+	if left != 80 || top != 5 || right != 720 || bottom != 300 {
+		t.Errorf("plot area is %v,%v to %v,%v", left, top, right, bottom)
+	}
+}
+
+func TestParseBackgroundColors(t *testing.T) {
+	raw := `Colors =
+  id:bars value:gray(0.95)
+BackgroundColors = bars:bars canvas:nope
+`
+	_, err := ParseTimeline(context.Background(), raw)
+	var errs ParseErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Token != "nope" {
+		t.Fatalf("expected an error for the undefined color, got %v", err)
+	}
+
+	tl, err := ParseTimeline(context.Background(), raw[:len(raw)-len(" canvas:nope\n")])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tl.Config.BackgroundColors != (BackgroundColors{Bars: "bars"}) {
+		t.Errorf("background colors are %+v", tl.Config.BackgroundColors)
+	}
+}
+
+func TestParseLegend(t *testing.T) {
+	raw := "Legend = columnwidth:120 left:50 top:10% orientation:horizontal position:right\n"
+	tl, err := ParseTimeline(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Legend{
+		Orientation: "horizontal",
+		Position:    "right",
+		Columns:     1,
+		ColumnWidth: Dimension{Defined: true, Value: 120},
+		Left:        Dimension{Defined: true, Value: 50},
+		Top:         Dimension{Defined: true, Value: 10, Percent: true},
+	}
+	if tl.Config.Legend != want {
+		t.Errorf("legend is %+v", tl.Config.Legend)
+	}
+}
+
+func TestParseTextData(t *testing.T) {
+	raw := `TextData =
+  pos:(10,40) fontsize:8 tabs:(60-left,200-right)
+  text:"Name^Joined^Left"
+  text:"Alpha^1991^1995~Beta^1993^"
+  text:Last line pos:(400,15)
+`
+	tl, err := ParseTimeline(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tl.TextItems) != 3 {
+		t.Fatalf("got %d text items", len(tl.TextItems))
+	}
+	first, second, third := tl.TextItems[0], tl.TextItems[1], tl.TextItems[2]
+	if first.X != 10 || first.Y != 40 || first.FontSize != (FontSize{Points: 8}) || first.Line != 0 ||
+		len(first.Tabs) != 2 || first.Tabs[1] != (TabStop{Pos: 200, Align: "right"}) {
+		t.Errorf("first item is %+v", first)
+	}
+	if second.Line != 1 || len(second.Lines()) != 2 || second.Lines()[1][1] != "1993" {
+		t.Errorf("second item is %+v", second)
+	}
+	if third.X != 400 || third.Line != 0 || third.Text != "Last line" {
+		t.Errorf("third item is %+v", third)
+	}
+}
+
+func TestParseDefine(t *testing.T) {
+	raw := `Define $start = 1990
+Define $now = $end
+Define $end = 2000
+DateFormat = yyyy
+Period = from:$start till:$now
+PlotData =
+  bar:A from:start till:$now shift:($dx,-4) text:"$5 show"
+Define $dx = 25
+`
+	tl, err := ParseTimeline(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tl.Config.Period.Start.Year() != 1990 || tl.PlotItems[0].Til.Year() != 2000 {
+		t.Errorf("period is %v - %v", tl.Config.Period.Start, tl.PlotItems[0].Til)
+	}
+	if tl.PlotItems[0].Text != "$5 show" {
+		t.Errorf("text is %q", tl.PlotItems[0].Text)
+	}
+
+	raw = `Define $a = $b
+Define $b = $a
+DateFormat = yyyy
+Period = from:$a till:$nope
+`
+	_, err = ParseTimeline(context.Background(), raw)
+	var errs ParseErrors
+	if !errors.As(err, &errs) || len(errs) < 2 {
+		t.Fatalf("expected errors, got %v", err)
+	}
+	if !strings.Contains(errs[0].Msg, "recursive") || !strings.Contains(errs[1].Msg, "$nope is not defined") {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestParsePreset(t *testing.T) {
+	raw := `Preset = TimeHorizontal_AutoPlaceBars_UnitYear
+PlotArea = left:80
+ImageSize = width:700
+DateFormat = yyyy
+Period = from:1970 till:2000
+PlotData =
+  bar:Band from:1970 till:1980 text:Formed
+`
+	tl, err := ParseTimeline(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the file's settings win, the rest come from the preset
+	if tl.Config.ImageSize.WidthPx != 700 || tl.Config.ImageSize.BarincrementPx != 25 {
+		t.Errorf("image size is %+v", tl.Config.ImageSize)
+	}
+	if tl.Config.PlotArea.Left.Value != 80 || tl.Config.PlotArea.Bottom.Value != 30 {
+		t.Errorf("plot area is %+v", tl.Config.PlotArea)
+	}
+	if tl.Config.AlignBars != "justify" || tl.Config.BackgroundColors.Canvas != "canvas" {
+		t.Errorf("config is %+v", tl.Config)
+	}
+	// and so do the PlotData defaults
+	if item := tl.PlotItems[0]; item.Align != "left" || item.Anchor != "from" || item.ShiftX != 4 || item.ShiftY != -4 {
+		t.Errorf("item is %+v", item)
+	}
+
+	_, err = ParseTimeline(context.Background(), "Preset = Nope\n")
+	if err == nil || !strings.Contains(err.Error(), "unknown preset") {
+		t.Errorf("expected an unknown preset error, got %v", err)
+	}
+}
+
+func TestParsePlotDataPoints(t *testing.T) {
+	raw := `DateFormat = yyyy
+Period = from:1970 till:2000
+Colors =
+  id:red value:red
+PlotData =
+  mark:(line,red)
+  bar:Band at:1980 text:Formed
+  bar:Band at:1990 mark:(line)
+  bar:Band from:1980 till:1990 color:red
+`
+	tl, err := ParseTimeline(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := tl.PlotItems
+	if len(items) != 3 {
+		t.Fatalf("expected 3 items, got %d", len(items))
+	}
+	if !items[0].Point || items[0].At.Year() != 1980 || items[0].Mark != (Mark{Style: "line", ColorID: "red"}) {
+		t.Errorf("first item is %+v", items[0])
+	}
+	if items[1].Mark != (Mark{Style: "line", ColorID: "black"}) {
+		t.Errorf("second item mark is %+v", items[1].Mark)
+	}
+	if items[2].Point {
+		t.Errorf("third item is %+v", items[2])
+	}
+
+	_, err = ParseTimeline(context.Background(), "PlotData =\n  bar:x at:01/01/2000 mark:(box)\n")
+	if err == nil {
+		t.Error("expected an error for an unknown mark")
+	}
+}
+
+func TestParsePlotDataTextPlacement(t *testing.T) {
+	raw := `DateFormat = yyyy
+Period = from:1970 till:2000
+PlotData =
+  align:center anchor:middle shift:(6,-4)
+  bar:Band from:1970 till:1980 text:Formed
+  bar:Band from:1980 till:1990 text:Split align:right anchor:till shift:(0,2)
+`
+	tl, err := ParseTimeline(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second := tl.PlotItems[0], tl.PlotItems[1]
+	if first.Align != "center" || first.Anchor != "middle" || first.ShiftX != 6 || first.ShiftY != -4 {
+		t.Errorf("first item is %+v", first)
+	}
+	if second.Align != "right" || second.Anchor != "till" || second.ShiftX != 0 || second.ShiftY != 2 {
+		t.Errorf("second item is %+v", second)
+	}
+	// without an anchor: the text is on the middle of the bar
+	if x := anchorPixel(PlotItem{Align: "center"}, 100, 300); x != 200 {
+		t.Errorf("align:center without an anchor is at %v", x)
+	}
+	if x := anchorPixel(second, 100, 300); x != 300 {
+		t.Errorf("anchor:till is at %v", x)
+	}
+	if tl.Config.PlotTextSize != (FontSize{Points: 8}) {
+		t.Errorf("default text size is %+v", tl.Config.PlotTextSize)
+	}
+
+	_, err = ParseTimeline(context.Background(), "PlotData =\n  align:middle\n")
+	if err == nil || !strings.Contains(err.Error(), "must be left, center or right") {
+		t.Errorf("expected an align error, got %v", err)
+	}
+}
+
+func TestParseLineData(t *testing.T) {
+	raw := `DateFormat = yyyy
+Period = from:1970 till:2000
+LineData =
+  layer:back color:red width:0.5
+  at:1980
+  at:1985 frompos:30 tillpos:50% layer:front
+  from:1972 till:1998 atpos:150
+  points:(10,10)(590,190)
+`
+	tl, err := ParseTimeline(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := tl.LineEvents
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 lines, got %d", len(lines))
+	}
+	if lines[0].Kind != "at" || lines[0].Layer != "back" || lines[0].Width != 0.5 || lines[0].ColorID != "red" {
+		t.Errorf("first line is %+v", lines[0])
+	}
+	if lines[1].Layer != "front" || lines[1].FromPos.Value != 30 || !lines[1].TillPos.Percent {
+		t.Errorf("second line is %+v", lines[1])
+	}
+	if lines[2].Kind != "fromtill" || lines[2].From.Year() != 1972 || lines[2].AtPos.Value != 150 {
+		t.Errorf("third line is %+v", lines[2])
+	}
+	if lines[3].Kind != "points" || lines[3].Points[1] != (Point{X: 590, Y: 190}) {
+		t.Errorf("fourth line is %+v", lines[3])
+	}
+
+	_, err = ParseTimeline(context.Background(), "Period = from:1970 till:2000\nDateFormat = yyyy\nLineData =\n  from:1972 till:1980\n")
+	if err == nil || !strings.Contains(err.Error(), "needs an atpos") {
+		t.Errorf("expected an atpos error, got %v", err)
+	}
+}
+
+func TestParseColors(t *testing.T) {
+	raw := `Colors =
+  id:band value:rgb(0.2,0.4,0.6)
+  id:bad  value:rgb(2,0,0)
+PlotData =
+  bar:Band from:01/01/1980 till:01/01/1990 color:band
+  bar:Band from:01/01/1990 till:01/01/2000 color:red
+  bar:Band from:01/01/2000 till:01/01/2010 color:nope
+`
+	_, err := ParseTimeline(context.Background(), raw)
+	var errs ParseErrors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", err)
+	}
+	if errs[0].Line != 3 || !strings.Contains(errs[0].Msg, "between 0 and 1") {
+		t.Errorf("first error is %+v", errs[0])
+	}
+	if errs[1].Line != 7 || !strings.Contains(errs[1].Msg, "not defined in Colors") {
+		t.Errorf("second error is %+v", errs[1])
+	}
+}
+
+func TestParseGridColor(t *testing.T) {
+	raw := `Colors =
+  id:grid value:gray(0.9)
+ScaleMajor = increment:5 start:1980 gridcolor:grid
+ScaleMinor = increment:1 start:1980 gridcolor:lightgray
+`
+	tl, err := ParseTimeline(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tl.Config.ScaleMajor.GridColor != "grid" || tl.Config.ScaleMinor.GridColor != "lightgray" {
+		t.Errorf("grid colors are %q and %q", tl.Config.ScaleMajor.GridColor, tl.Config.ScaleMinor.GridColor)
+	}
+
+	_, err = ParseTimeline(context.Background(), "ScaleMajor = increment:5 gridcolor:nope\n")
+	if err == nil || !strings.Contains(err.Error(), "not defined in Colors") {
+		t.Errorf("expected a color error, got %v", err)
+	}
+}
+
+func TestParseBarsets(t *testing.T) {
+	raw := `DateFormat = yyyy
+Period = from:1970 till:2000
+BarData =
+  bar:Ian text:"Ian Curtis" link:https://example.com/ian
+  barset:Gigs text:Gigs
+PlotData =
+  bar:Ian from:1975 till:1980
+  barset:Gigs
+  at:1976 text:One
+  at:1977 text:Two
+  barset:break
+  at:1978 text:Three
+  barset:skip
+  at:1979 text:Four
+  bar:Ian from:1981 till:1982
+`
+	tl, err := ParseTimeline(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ian := tl.Bars["Ian"]; ian.Text != "Ian Curtis" || ian.Link != "https://example.com/ian" || ian.Barset {
+		t.Errorf("Ian is %+v", ian)
+	}
+	if !tl.Bars["Gigs"].Barset {
+		t.Errorf("Gigs is %+v", tl.Bars["Gigs"])
+	}
+	var rows []int
+	for _, item := range tl.PlotItems[1:5] {
+		if item.BarID != "Gigs" {
+			t.Errorf("item is on %q", item.BarID)
+		}
+		rows = append(rows, item.BarsetRow)
+	}
+	if !slices.Equal(rows, []int{0, 1, 0, 2}) {
+		t.Errorf("barset rows are %v", rows)
+	}
+	if item := tl.PlotItems[5]; item.BarID != "Ian" || item.BarsetRow != 0 {
+		t.Errorf("last item is %+v", item)
+	}
+	want := []barRow{{ID: "Ian", Label: "Ian Curtis"}, {ID: "Gigs", Label: "Gigs"}, {ID: "Gigs", Row: 1}, {ID: "Gigs", Row: 2}}
+	if rows := tl.barRows(); !slices.Equal(rows, want) {
+		t.Errorf("rows are %+v", rows)
+	}
+}
+
+func TestParseFontSizeKeywords(t *testing.T) {
+	raw := `PlotData =
+  fontsize:S
+TextData =
+  pos:(10,40) fontsize:xl text:Caption
+`
+	tl, err := ParseTimeline(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tl.Config.PlotTextSize != (FontSize{Keyword: "S"}) || tl.TextItems[0].FontSize != (FontSize{Keyword: "XL"}) {
+		t.Errorf("font sizes are %+v and %+v", tl.Config.PlotTextSize, tl.TextItems[0].FontSize)
+	}
+	if p := tl.points(tl.TextItems[0].FontSize); p != 14 {
+		t.Errorf("XL is %v points", p)
+	}
+	tl.Defaults.FontSizes = map[string]int{"XL": 20}
+	if p := tl.points(tl.TextItems[0].FontSize); p != 20 {
+		t.Errorf("XL is %v points with our own sizes", p)
+	}
+	if p := tl.points(tl.Config.PlotTextSize); p != 8 {
+		t.Errorf("S is %v points with our own sizes", p)
+	}
+
+	big, err := ParseTimeline(context.Background(), "DateFormat = yyyy\nPlotData =\n  bar:A at:1980 text:Big fontsize:XL textcolor:red\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i := big.PlotItems[0]; i.FontSize != (FontSize{Keyword: "XL"}) || i.TextColor != "red" || len(big.Diagnostics) != 0 {
+		t.Errorf("item is %+v, with %v", i, big.Diagnostics)
+	}
+
+	_, err = ParseTimeline(context.Background(), "PlotData =\n  fontsize:huge\n")
+	if err == nil || !strings.Contains(err.Error(), "couldn't parse fontsize") {
+		t.Errorf("expected a fontsize error, got %v", err)
+	}
+}
+
+func TestParseWikiLinks(t *testing.T) {
+	raw := `DateFormat = yyyy
+Period = from:1970 till:2000
+BarData =
+  bar:Robert text:"[[Robert Smith (musician)|Robert Smith]]"
+  bar:Lol text:[[Lol Tolhurst]] link:https://example.com/lol
+PlotData =
+  bar:Robert from:1978 till:end text:[[Three Imaginary Boys]] tour
+TextData =
+  pos:(10,40) text:"Source: [[The Cure|the band]] and [[Fiction Records]]"
+`
+	tl, err := ParseTimeline(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := tl.Bars["Robert"]; b.Text != "Robert Smith" || b.Link != "Robert Smith (musician)" {
+		t.Errorf("Robert is %+v", b)
+	}
+	if b := tl.Bars["Lol"]; b.Text != "Lol Tolhurst" || b.Link != "https://example.com/lol" {
+		t.Errorf("Lol is %+v", b)
+	}
+	if item := tl.PlotItems[0]; item.Text != "Three Imaginary Boys tour" || item.Link != "Three Imaginary Boys" {
+		t.Errorf("item is %+v", item)
+	}
+	if item := tl.TextItems[0]; item.Text != "Source: the band and Fiction Records" || item.Link != "The Cure" {
+		t.Errorf("text is %+v", item)
+	}
+}
+
+func TestParseModes(t *testing.T) {
+	raw := `DateFormat = yyyy
+Period = from:1970 till:2000
+Wibble = foo:bar
+BarData =
+  bar:A text:Alpha sparkle:yes
+PlotData =
+  bar:A from:1972 till:1980 frobnicate:1
+  bar:A from:19x5 till:1990 frobnicate:2
+oops
+`
+	// the bad date is an error, the rest are only diagnostics
+	_, err := ParseTimeline(context.Background(), raw)
+	var errs ParseErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Line != 8 {
+		t.Errorf("normal mode gave %v", err)
+	}
+
+	tl, err := ParseTimeline(context.Background(), raw, WithMode(Lenient))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tl.PlotItems) != 1 || tl.Bars["A"].Text != "Alpha" {
+		t.Errorf("lenient mode kept %+v and %+v", tl.PlotItems, tl.Bars)
+	}
+	var lines []int
+	for _, d := range tl.Diagnostics {
+		lines = append(lines, d.Line)
+	}
+	if !slices.Equal(lines, []int{3, 5, 7, 8, 8, 9}) {
+		t.Errorf("diagnostics are on lines %v: %v", lines, tl.Diagnostics)
+	}
+	want := []string{"Wibble ignored", "BarData: sparkle: ignored", "PlotData: frobnicate: ignored (2 times)"}
+	if got := tl.Diagnostics.Unsupported(); !slices.Equal(got, want) {
+		t.Errorf("unsupported is %q", got)
+	}
+
+	_, err = ParseTimeline(context.Background(), raw, WithMode(Strict))
+	if !errors.As(err, &errs) || len(errs) != 6 {
+		t.Errorf("strict mode gave %v", err)
+	}
+	if _, err := ParseTimeline(context.Background(), "DateFormat = yyyy\nTimeAxis = orientation:vertical\n", WithMode(Strict)); err != nil {
+		t.Errorf("strict mode failed on a supported file: %v", err)
+	}
+}
+
+func TestParsePlotDataDefaults(t *testing.T) {
+	raw := `DateFormat = yyyy
+Period = from:1970 till:2000
+Colors =
+  id:a value:red
+  id:b value:blue
+PlotData =
+  bar:Leaders color:a text:[[Leader]]
+  from:1970 till:1980
+  from:1980 till:1990 color:b text:Second
+  link:https://example.com/third text:Third
+  from:1990 till:2000
+`
+	tl, err := ParseTimeline(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second := tl.PlotItems[0], tl.PlotItems[1]
+	if first.BarID != "Leaders" || first.ColorID != "a" || first.Text != "Leader" || first.Link != "Leader" {
+		t.Errorf("first item is %+v", first)
+	}
+	if second.BarID != "Leaders" || second.ColorID != "b" || second.Text != "Second" || second.Link != "" {
+		t.Errorf("second item is %+v", second)
+	}
+	if third := tl.PlotItems[2]; third.Text != "Third" || third.Link != "https://example.com/third" {
+		t.Errorf("third item is %+v", third)
+	}
+	if len(tl.Diagnostics) != 0 {
+		t.Errorf("diagnostics are %v", tl.Diagnostics)
+	}
+}
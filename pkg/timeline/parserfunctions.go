@@ -0,0 +1,410 @@
+// MediaWiki parser functions and magic words, like {{#time:Y}},
+// {{CURRENTYEAR}} and {{#expr:2000+25}}, which Wikipedia timelines
+// use to run up to today. Only the common ones are here; anything
+// else is left as it is.
+package timeline
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// evaluateParserFunctions replaces the parser functions and magic
+// words in every value with their results
+func (p *parser) evaluateParserFunctions(statements []*statement) {
+	now := p.opts.now().In(p.opts.location)
+	for _, s := range statements {
+		if strings.Contains(s.Value, "{{") {
+			a := attribute{Value: s.Value, ValuePos: s.ValuePos}
+			value, err := evaluateWikitext(s.Value, now)
+			if err != nil {
+				p.errorf(s, a, "%s", err.Error())
+			}
+			s.Value = value
+		}
+		for _, attrs := range s.Lines {
+			for i := range attrs {
+				if !strings.Contains(attrs[i].Value, "{{") {
+					continue
+				}
+				value, err := evaluateWikitext(attrs[i].Value, now)
+				if err != nil {
+					p.errorf(s, attrs[i], "%s", err.Error())
+				}
+				attrs[i].Value = value
+			}
+		}
+	}
+}
+
+// evaluateWikitext evaluates the {{...}} in text, innermost first so
+// that {{#expr:{{CURRENTYEAR}}+1}} works
+func evaluateWikitext(text string, now time.Time) (string, error) {
+	var sb strings.Builder
+	for {
+		start := strings.Index(text, "{{")
+		if start < 0 {
+			break
+		}
+		end := matchingBraces(text, start)
+		if end < 0 {
+			break
+		}
+		inner, err := evaluateWikitext(text[start+2:end], now)
+		if err != nil {
+			return text, err
+		}
+		result, ok, err := parserFunction(inner, now)
+		if err != nil {
+			return text, err
+		}
+		if !ok {
+			// not one of ours, so leave it for whoever reads it
+			result = "{{" + inner + "}}"
+		}
+		sb.WriteString(text[:start])
+		sb.WriteString(result)
+		text = text[end+2:]
+	}
+	sb.WriteString(text)
+	return sb.String(), nil
+}
+
+// matchingBraces finds the "}}" that closes the "{{" at text[start]
+func matchingBraces(text string, start int) int {
+	depth := 0
+	for i := start; i+1 < len(text); i++ {
+		switch text[i : i+2] {
+		case "{{":
+			depth++
+			i++
+		case "}}":
+			depth--
+			if depth == 0 {
+				return i
+			}
+			i++
+		}
+	}
+	return -1
+}
+
+// parserFunction works out one {{...}}; ok is false if it isn't one
+// of the functions or magic words we know about
+func parserFunction(inner string, now time.Time) (result string, ok bool, err error) {
+	name, args, _ := strings.Cut(inner, ":")
+	switch strings.TrimSpace(name) {
+	case "CURRENTYEAR":
+		return strconv.Itoa(now.Year()), true, nil
+	case "CURRENTMONTH":
+		return fmt.Sprintf("%02d", now.Month()), true, nil
+	case "CURRENTDAY":
+		return strconv.Itoa(now.Day()), true, nil
+	case "CURRENTDAY2":
+		return fmt.Sprintf("%02d", now.Day()), true, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "#time":
+		format, date, _ := strings.Cut(args, "|")
+		t, err := timeArgument(date, now)
+		if err != nil {
+			return "", true, err
+		}
+		return phpDate(strings.TrimSpace(format), t), true, nil
+	case "#expr":
+		v, err := evaluateExpr(args)
+		if err != nil {
+			return "", true, fmt.Errorf("couldn't work out {{#expr:%s}}: %w", args, err)
+		}
+		return formatNumber(v), true, nil
+	}
+	return "", false, nil
+}
+
+// relativeTimeRe matches a part of a relative time like "+1 year"
+var relativeTimeRe = regexp.MustCompile(`^([+-]?\d+)\s*(year|month|week|day|hour|minute|second)s?\b`)
+
+// timeArgument reads the date in {{#time:format|date}}: nothing (or
+// "now") for now, a date like 2025-03-01, or a relative time like
+// "+1 year -2 days"
+func timeArgument(arg string, now time.Time) (time.Time, error) {
+	arg = strings.TrimSpace(arg)
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04:05", "2 January 2006", "January 2, 2006", "2006"} {
+		if t, err := time.ParseInLocation(layout, arg, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	t := now
+	rest := strings.ToLower(arg)
+	rest = strings.TrimSpace(strings.TrimPrefix(rest, "now"))
+	rest = strings.TrimSpace(strings.TrimPrefix(rest, "today"))
+	for rest != "" {
+		m := relativeTimeRe.FindStringSubmatch(rest)
+		if m == nil {
+			return time.Time{}, fmt.Errorf("couldn't understand the date %q in {{#time}}", arg)
+		}
+		n, _ := strconv.Atoi(m[1])
+		switch m[2] {
+		case "year":
+			t = t.AddDate(n, 0, 0)
+		case "month":
+			t = t.AddDate(0, n, 0)
+		case "week":
+			t = t.AddDate(0, 0, 7*n)
+		case "day":
+			t = t.AddDate(0, 0, n)
+		case "hour":
+			t = t.Add(time.Duration(n) * time.Hour)
+		case "minute":
+			t = t.Add(time.Duration(n) * time.Minute)
+		case "second":
+			t = t.Add(time.Duration(n) * time.Second)
+		}
+		rest = strings.TrimSpace(rest[len(m[0]):])
+	}
+	return t, nil
+}
+
+// phpDate formats a time with the PHP date() codes that {{#time}}
+// uses, e.g. "d/m/Y"; a backslash or double quotes keep characters
+// as they are
+func phpDate(format string, t time.Time) string {
+	var sb strings.Builder
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		switch c {
+		case '\\':
+			if i+1 < len(format) {
+				i++
+				sb.WriteByte(format[i])
+			}
+		case '"':
+			end := strings.IndexByte(format[i+1:], '"')
+			if end < 0 {
+				sb.WriteString(format[i+1:])
+				return sb.String()
+			}
+			sb.WriteString(format[i+1 : i+1+end])
+			i += end + 1
+		case 'Y':
+			sb.WriteString(strconv.Itoa(t.Year()))
+		case 'y':
+			sb.WriteString(t.Format("06"))
+		case 'm':
+			sb.WriteString(t.Format("01"))
+		case 'n':
+			sb.WriteString(strconv.Itoa(int(t.Month())))
+		case 'd':
+			sb.WriteString(t.Format("02"))
+		case 'j':
+			sb.WriteString(strconv.Itoa(t.Day()))
+		case 'M':
+			sb.WriteString(t.Format("Jan"))
+		case 'F':
+			sb.WriteString(t.Format("January"))
+		case 'D':
+			sb.WriteString(t.Format("Mon"))
+		case 'l':
+			sb.WriteString(t.Format("Monday"))
+		case 'H':
+			sb.WriteString(t.Format("15"))
+		case 'G':
+			sb.WriteString(strconv.Itoa(t.Hour()))
+		case 'i':
+			sb.WriteString(t.Format("04"))
+		case 's':
+			sb.WriteString(t.Format("05"))
+		case 'U':
+			sb.WriteString(strconv.FormatInt(t.Unix(), 10))
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// formatNumber writes a number the way {{#expr}} does, without a
+// decimal point if it is a whole number
+func formatNumber(v float64) string {
+	if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+		return strconv.FormatInt(int64(v), 10)
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// evaluateExpr works out an {{#expr}} expression, which has numbers,
+// parentheses, unary + and -, the functions abs, floor, ceil and
+// trunc, and the operators ^, *, /, div, mod, +, - and round, from
+// the highest precedence to the lowest
+func evaluateExpr(expr string) (float64, error) {
+	e := &exprParser{tokens: exprTokens(expr)}
+	v, err := e.round()
+	if err != nil {
+		return 0, err
+	}
+	if e.pos < len(e.tokens) {
+		return 0, fmt.Errorf("unexpected %q", e.tokens[e.pos])
+	}
+	return v, nil
+}
+
+// exprTokens splits an expression into numbers, words and operators
+func exprTokens(expr string) []string {
+	var tokens []string
+	for i := 0; i < len(expr); {
+		c := rune(expr[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || c == '.':
+			j := i
+			for j < len(expr) && (unicode.IsDigit(rune(expr[j])) || expr[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, expr[i:j])
+			i = j
+		case unicode.IsLetter(c):
+			j := i
+			for j < len(expr) && unicode.IsLetter(rune(expr[j])) {
+				j++
+			}
+			tokens = append(tokens, strings.ToLower(expr[i:j]))
+			i = j
+		default:
+			tokens = append(tokens, string(c))
+			i++
+		}
+	}
+	return tokens
+}
+
+// exprParser is a recursive descent parser for evaluateExpr, with a
+// method for each level of precedence
+type exprParser struct {
+	tokens []string
+	pos    int
+}
+
+func (e *exprParser) peek() string {
+	if e.pos < len(e.tokens) {
+		return e.tokens[e.pos]
+	}
+	return ""
+}
+
+func (e *exprParser) round() (float64, error) {
+	v, err := e.sum()
+	for err == nil && e.peek() == "round" {
+		e.pos++
+		var places float64
+		places, err = e.sum()
+		scale := math.Pow(10, math.Trunc(places))
+		v = math.Round(v*scale) / scale
+	}
+	return v, err
+}
+
+func (e *exprParser) sum() (float64, error) {
+	v, err := e.product()
+	for err == nil && (e.peek() == "+" || e.peek() == "-") {
+		op := e.peek()
+		e.pos++
+		var w float64
+		w, err = e.product()
+		if op == "+" {
+			v += w
+		} else {
+			v -= w
+		}
+	}
+	return v, err
+}
+
+func (e *exprParser) product() (float64, error) {
+	v, err := e.power()
+	for err == nil {
+		op := e.peek()
+		if op != "*" && op != "/" && op != "div" && op != "mod" {
+			break
+		}
+		e.pos++
+		var w float64
+		w, err = e.power()
+		if err != nil {
+			break
+		}
+		switch op {
+		case "*":
+			v *= w
+		case "/", "div":
+			if w == 0 {
+				return 0, errors.New("division by zero")
+			}
+			v /= w
+		case "mod":
+			if math.Trunc(w) == 0 {
+				return 0, errors.New("division by zero")
+			}
+			v = float64(int64(v) % int64(w))
+		}
+	}
+	return v, err
+}
+
+func (e *exprParser) power() (float64, error) {
+	v, err := e.unary()
+	if err == nil && e.peek() == "^" {
+		e.pos++
+		var w float64
+		w, err = e.power()
+		v = math.Pow(v, w)
+	}
+	return v, err
+}
+
+func (e *exprParser) unary() (float64, error) {
+	switch op := e.peek(); op {
+	case "-", "+", "abs", "floor", "ceil", "trunc":
+		e.pos++
+		v, err := e.unary()
+		switch op {
+		case "-":
+			v = -v
+		case "abs":
+			v = math.Abs(v)
+		case "floor":
+			v = math.Floor(v)
+		case "ceil":
+			v = math.Ceil(v)
+		case "trunc":
+			v = math.Trunc(v)
+		}
+		return v, err
+	case "(":
+		e.pos++
+		v, err := e.round()
+		if err != nil {
+			return 0, err
+		}
+		if e.peek() != ")" {
+			return 0, errors.New("missing )")
+		}
+		e.pos++
+		return v, nil
+	case "":
+		return 0, errors.New("unexpected end of the expression")
+	default:
+		e.pos++
+		v, err := strconv.ParseFloat(op, 64)
+		if err != nil {
+			return 0, fmt.Errorf("unexpected %q", op)
+		}
+		return v, nil
+	}
+}
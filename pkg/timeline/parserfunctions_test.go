@@ -0,0 +1,90 @@
+package timeline
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEvaluateWikitext(t *testing.T) {
+	now := time.Date(2025, time.March, 7, 12, 30, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		text string
+		want string
+	}{
+		{"{{#time:d/m/Y}}", "07/03/2025"},
+		{"{{#time:Y}}", "2025"},
+		{"{{#time:d/m/Y|+1 year}}", "07/03/2026"},
+		{"{{#time:j F Y|now -2 months +1 day}}", "8 January 2025"},
+		{"{{#time:Y-m-d|1999-12-31}}", "1999-12-31"},
+		{`{{#time:"week" W}}`, "week W"},
+		{"till:{{CURRENTYEAR}} and {{CURRENTMONTH}}/{{CURRENTDAY2}}", "till:2025 and 03/07"},
+		{"{{#expr:{{CURRENTYEAR}}+1}}", "2026"},
+		{"{{#expr: (2 + 3) * 4 - 10 / 4}}", "17.5"},
+		{"{{#expr: 7 mod 3 + 2^3}}", "9"},
+		{"{{#expr: 10 / 3 round 2}}", "3.33"},
+		{"{{#expr: -floor 2.5}}", "-2"},
+		{"{{Citation needed}} and {{#expr:1+1}}", "{{Citation needed}} and 2"},
+	} {
+		got, err := evaluateWikitext(tc.text, now)
+		if err != nil {
+			t.Errorf("%s: %v", tc.text, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.text, got, tc.want)
+		}
+	}
+
+	for _, text := range []string{"{{#expr:1/0}}", "{{#expr:(1+2}}", "{{#expr:1 +}}", "{{#time:Y|next blue moon}}"} {
+		if _, err := evaluateWikitext(text, now); err == nil {
+			t.Errorf("%s: expected an error", text)
+		}
+	}
+}
+
+func TestParseWithClock(t *testing.T) {
+	raw := `DateFormat = dd/mm/yyyy
+Define $end = {{#time:d/m/Y|+1 year}}
+Period = from:01/01/2000 till:$end
+ScaleMajor = increment:5 start:{{#expr:{{CURRENTYEAR}}-25}}
+`
+	clock := func() time.Time { return time.Date(2025, time.March, 7, 0, 0, 0, 0, time.UTC) }
+	tl, err := ParseTimeline(context.Background(), raw, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if end := tl.Config.Period.End; !end.Equal(time.Date(2026, time.March, 7, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("end is %v", end)
+	}
+	if start := tl.Config.ScaleMajor.Start; start.Year() != 2000 {
+		t.Errorf("scale start is %v", start)
+	}
+}
+
+func TestParseWithLocation(t *testing.T) {
+	raw := `DateFormat = dd/mm/yyyy
+Period = from:01/01/2000 till:{{#time:d/m/Y}}
+`
+	// 11pm on New Year's Eve in UTC is already 2026 in Auckland
+	clock := func() time.Time { return time.Date(2025, time.December, 31, 23, 0, 0, 0, time.UTC) }
+	tl, err := ParseTimeline(context.Background(), raw, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if end := tl.Config.Period.End; end.Year() != 2025 || end.Location() != time.UTC {
+		t.Errorf("end is %v", end)
+	}
+
+	auckland, err := time.LoadLocation("Pacific/Auckland")
+	if err != nil {
+		t.Skip(err)
+	}
+	tl, err = ParseTimeline(context.Background(), raw, WithClock(clock), WithLocation(auckland))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if end := tl.Config.Period.End; end.Year() != 2026 || end.Location() != auckland {
+		t.Errorf("end in Auckland is %v", end)
+	}
+}
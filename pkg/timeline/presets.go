@@ -0,0 +1,40 @@
+package timeline
+
+// presets are the settings implied by a "Preset =" line, written in
+// the same syntax as a timeline file; they come before everything
+// else in the file, so anything the file sets itself wins. These
+// follow the presets in the EasyTimeline documentation.
+var presets = map[string]string{
+	// one vertical bar, like a list of leaders or eras
+	"TimeVertical_OneBar_UnitYear": `
+Colors =
+  id:canvas value:gray(0.97)
+  id:grid1  value:gray(0.86)
+  id:grid2  value:gray(0.7)
+ImageSize  = width:160
+PlotArea   = left:45 right:10 top:10 bottom:10
+TimeAxis   = orientation:vertical format:yyyy
+AlignBars  = early
+ScaleMajor = unit:year increment:10 gridcolor:grid2
+ScaleMinor = unit:year increment:1
+BackgroundColors = canvas:canvas
+PlotData =
+  width:20 textcolor:black align:left anchor:from shift:(25,-5) fontsize:8
+`,
+	// horizontal bars, one for each bar used in PlotData
+	"TimeHorizontal_AutoPlaceBars_UnitYear": `
+Colors =
+  id:canvas value:gray(0.97)
+  id:grid1  value:gray(0.86)
+  id:grid2  value:gray(0.7)
+ImageSize  = width:800 barincrement:25
+PlotArea   = left:25 right:25 top:15 bottom:30
+TimeAxis   = orientation:horizontal format:yyyy
+AlignBars  = justify
+ScaleMajor = unit:year increment:10 gridcolor:grid2
+ScaleMinor = unit:year increment:1
+BackgroundColors = canvas:canvas
+PlotData =
+  width:15 textcolor:black align:left anchor:from shift:(4,-4) fontsize:8
+`,
+}
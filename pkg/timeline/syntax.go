@@ -0,0 +1,318 @@
+// The grammar for the EasyTimeline format, as described at
+// https://en.wikipedia.org/wiki/Help:EasyTimeline_syntax
+//
+// This replaces the early PEG draft; it reads a file into a list of
+// commands and their attributes and leaves the meaning of each
+// command to ParseTimeline.
+//
+//	file       = { line } ;
+//	line       = blank | comment | command | data ;
+//	command    = name [ ws ] "=" [ ws ] [ attributes | value ] eol ;  (starts in column 1)
+//	define     = "Define" ws "$" name [ ws ] "=" [ ws ] value eol ;
+//	data       = ws attributes eol ;                                 (belongs to the last command)
+//	attributes = attribute { ws attribute } ;
+//	attribute  = key ":" [ ws ] value ;
+//	value      = { quoted | group | char } ;  (runs up to the next " key:" or the end of the line)
+//	quoted     = '"' { char } '"' ;
+//	group      = "(" value ")" | "{{" value "}}" | "[[" value "]]" ;
+//	comment    = "#" { char } eol | "#>" { char } "<#" | "%" { char } eol ;
+//
+// Command names and attribute keys are case insensitive; "Alignbars" is
+// the same command as "AlignBars" and "Bar:" is the same key as "bar:".
+package timeline
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// position is a 1-based line and column in the source text
+type position struct {
+	Line   int
+	Column int
+}
+
+// attribute is a single key:value pair
+type attribute struct {
+	Key      string // always lower case
+	Value    string
+	Pos      position
+	ValuePos position
+}
+
+// statement is a command and all of the attribute lines that belong
+// to it; the attributes written after the "=" on the command line
+// itself, if any, are the first line
+type statement struct {
+	Name     string // as written in the file
+	Pos      position
+	Constant string // the "$name" in a Define
+	Value    string // for commands like "DateFormat = yyyy" that take a plain value
+	ValuePos position
+	Lines    [][]attribute
+}
+
+// syntaxError is a problem found while reading the structure of the
+// file, before any of the commands are interpreted
+type syntaxError struct {
+	Pos     position
+	Token   string
+	Section string // the command the line belongs to, if any
+	Msg     string
+}
+
+func (e syntaxError) Error() string {
+	return fmt.Sprintf("%d:%d: %s (near %q)", e.Pos.Line, e.Pos.Column, e.Msg, e.Token)
+}
+
+// Regex for command lines (e.g., `ImageSize = width:800` or `Colors =`)
+var commandRe = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9]*)\s*=\s*(.*)$`)
+
+// Regex for constant definitions (e.g., `Define $now = 01/01/2025`)
+var defineRe = regexp.MustCompile(`^(?i:define)\s+(\$[A-Za-z_]\w*)\s*=\s*(.*)$`)
+
+// Regex for the start of an attribute (e.g., `bar:` or `Bar :`)
+var keyRe = regexp.MustCompile(`^([A-Za-z]+):`)
+
+// replace spaces, including unicode spaces
+var replaceSpacesRe = regexp.MustCompile(`\p{Zs}`)
+
+// parseStatements reads the raw text into a list of statements; it
+// keeps going after a syntax error so all of them can be reported
+func parseStatements(raw string) ([]*statement, []syntaxError) {
+	var statements []*statement
+	var errs []syntaxError
+	var current *statement
+
+	raw = blankBlockComments(raw)
+	scanner := bufio.NewScanner(strings.NewReader(raw))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := replaceSpacesRe.ReplaceAllString(scanner.Text(), " ")
+		line = strings.ReplaceAll(line, "\t", " ")
+		line = stripComment(line)
+		line = strings.TrimRightFunc(line, unicode.IsSpace)
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		// lines that start in the first column are commands, the
+		// rest are data for the last command
+		if line[0] != ' ' {
+			if matches := defineRe.FindStringSubmatchIndex(line); matches != nil {
+				current = &statement{
+					Name:     "Define",
+					Pos:      position{lineNo, 1},
+					Constant: line[matches[2]:matches[3]],
+					Value:    line[matches[4]:matches[5]],
+					ValuePos: position{lineNo, matches[4] + 1},
+				}
+				statements = append(statements, current)
+				continue
+			}
+			matches := commandRe.FindStringSubmatchIndex(line)
+			if matches == nil {
+				errs = append(errs, syntaxError{
+					Pos:   position{lineNo, 1},
+					Token: firstField(line),
+					Msg:   "expected a command like \"Name = ...\"",
+				})
+				current = nil
+				continue
+			}
+			current = &statement{
+				Name: line[matches[2]:matches[3]],
+				Pos:  position{lineNo, 1},
+			}
+			statements = append(statements, current)
+			rest := line[matches[4]:matches[5]]
+			col := matches[4] + 1
+			switch {
+			case rest == "":
+			case keyRe.MatchString(rest):
+				attrs, attrErrs := parseAttributes(rest, lineNo, col)
+				current.Lines = append(current.Lines, attrs)
+				for _, err := range attrErrs {
+					err.Section = current.Name
+					errs = append(errs, err)
+				}
+			default:
+				current.Value = rest
+				current.ValuePos = position{lineNo, col}
+			}
+			continue
+		}
+
+		if current == nil {
+			errs = append(errs, syntaxError{
+				Pos:   position{lineNo, indent(line) + 1},
+				Token: firstField(line),
+				Msg:   "data line does not belong to a command",
+			})
+			continue
+		}
+		col := indent(line)
+		attrs, attrErrs := parseAttributes(line[col:], lineNo, col+1)
+		for _, err := range attrErrs {
+			err.Section = current.Name
+			errs = append(errs, err)
+		}
+		if len(attrs) > 0 {
+			current.Lines = append(current.Lines, attrs)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, syntaxError{Pos: position{lineNo, 1}, Msg: err.Error()})
+	}
+
+	return statements, errs
+}
+
+// parseAttributes splits a line like
+//
+//	bar:Robert from:01/05/1978 till:end color:lvocals text:Robert Smith
+//
+// into its attributes; col is the column where s starts in the line
+func parseAttributes(s string, line, col int) ([]attribute, []syntaxError) {
+	var attrs []attribute
+	var errs []syntaxError
+
+	i := 0
+	for i < len(s) {
+		if s[i] == ' ' {
+			i++
+			continue
+		}
+		matches := keyRe.FindStringSubmatch(s[i:])
+		if matches == nil {
+			// skip ahead to the next thing that looks like an attribute
+			end := valueEnd(s, i)
+			errs = append(errs, syntaxError{
+				Pos:   position{line, col + i},
+				Token: strings.TrimSpace(s[i:end]),
+				Msg:   "expected an attribute like \"key:value\"",
+			})
+			i = end
+			continue
+		}
+		attr := attribute{
+			Key: strings.ToLower(matches[1]),
+			Pos: position{line, col + i},
+		}
+		i += len(matches[0])
+		for i < len(s) && s[i] == ' ' {
+			i++
+		}
+		end := valueEnd(s, i)
+		attr.Value = strings.TrimSpace(s[i:end])
+		attr.ValuePos = position{line, col + i}
+		attrs = append(attrs, attr)
+		i = end
+	}
+
+	return attrs, errs
+}
+
+// valueEnd finds where the value that starts at s[i] ends, which is
+// either the end of the line or the space before the next "key:";
+// anything in quotes, parentheses, {{...}} or [[...]] is kept together
+func valueEnd(s string, i int) int {
+	depth := 0
+	quoted := false
+	for j := i; j < len(s); j++ {
+		switch {
+		case s[j] == '"':
+			quoted = !quoted
+		case quoted:
+		case s[j] == '(':
+			depth++
+		case strings.HasPrefix(s[j:], "{{"), strings.HasPrefix(s[j:], "[["):
+			depth++
+			j++
+		case s[j] == ')':
+			depth = max(depth-1, 0)
+		case strings.HasPrefix(s[j:], "}}"), strings.HasPrefix(s[j:], "]]"):
+			depth = max(depth-1, 0)
+			j++
+		case s[j] == ' ' && depth == 0:
+			k := j
+			for k < len(s) && s[k] == ' ' {
+				k++
+			}
+			if keyRe.MatchString(s[k:]) {
+				return j
+			}
+		}
+	}
+	return len(s)
+}
+
+// Regex for a key at the end of some text (e.g., `id:red value:`),
+// where the value that follows it starts
+var keyBeforeValueRe = regexp.MustCompile(`(^|\s)[A-Za-z]+:\s*$`)
+
+// stripComment removes a "#" or "%" comment from the end of a line;
+// "#" only starts a comment at the beginning of a word that isn't the
+// start of a value, so values like {{#time:Y}} and "value: #336699"
+// are left alone
+func stripComment(line string) string {
+	if strings.HasPrefix(strings.TrimSpace(line), "%") {
+		return ""
+	}
+	quoted := false
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '"':
+			quoted = !quoted
+		case line[i] == '#' && !quoted && (i == 0 || line[i-1] == ' ') &&
+			!keyBeforeValueRe.MatchString(line[:i]):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// blankBlockComments replaces "#> ... <#" comments with spaces, keeping
+// the newlines so that line and column numbers are unchanged
+func blankBlockComments(raw string) string {
+	var sb strings.Builder
+	for {
+		start := strings.Index(raw, "#>")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(raw[start:], "<#")
+		if end < 0 {
+			end = len(raw)
+		} else {
+			end += start + 2
+		}
+		sb.WriteString(raw[:start])
+		for _, r := range raw[start:end] {
+			if r == '\n' {
+				sb.WriteRune('\n')
+			} else {
+				sb.WriteRune(' ')
+			}
+		}
+		raw = raw[end:]
+	}
+	sb.WriteString(raw)
+	return sb.String()
+}
+
+func indent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func firstField(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
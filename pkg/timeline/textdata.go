@@ -0,0 +1,55 @@
+package timeline
+
+import (
+	"image/color"
+	"strings"
+)
+
+// Lines splits the text into lines, and each line into the pieces
+// that go at each tab stop
+func (ti TextItem) Lines() [][]string {
+	var lines [][]string
+	for line := range strings.SplitSeq(ti.Text, "~") {
+		lines = append(lines, strings.Split(line, "^"))
+	}
+	return lines
+}
+
+// DrawText draws the TextData items; their positions are measured from
+// the bottom left of the image
+func (t *Timeline) DrawText(imageHeight float64) {
+	gc := t.Defaults.GraphicsContext
+	for _, item := range t.TextItems {
+		fontSize := float64(t.Defaults.FontSize)
+		if size := t.points(item.FontSize); size > 0 {
+			fontSize = size
+		}
+		gc.SetFontSize(fontSize)
+		gc.SetFillColor(color.RGBA{0, 0, 0, 255})
+		if item.TextColor != "" {
+			gc.SetFillColor(t.color(item.TextColor))
+		}
+		lineHeight := fontSize*4/3 + float64(t.Defaults.FontLeading)/2 // convert pts to pixels
+		for i, line := range item.Lines() {
+			y := imageHeight - item.Y + float64(item.Line+i)*lineHeight
+			for j, piece := range line {
+				x := item.X
+				align := "left"
+				if j > 0 && j <= len(item.Tabs) {
+					x += item.Tabs[j-1].Pos
+					align = item.Tabs[j-1].Align
+				}
+				left, _, right, _ := gc.GetStringBounds(piece)
+				switch align {
+				case "center":
+					x -= (right - left) / 2
+				case "right":
+					x -= right - left
+				}
+				gc.FillStringAt(piece, x, y)
+			}
+		}
+	}
+	gc.SetFontSize(float64(t.Defaults.FontSize))
+	gc.SetFillColor(color.RGBA{0, 0, 0, 255})
+}
@@ -1,7 +1,7 @@
 package timeline
 
 import (
-	"time"
+	"image/color"
 
 	"github.com/llgcode/draw2d/draw2dimg"
 )
@@ -15,22 +15,33 @@ type Timeline struct {
 	Bars       map[string]Bar
 	PlotItems  []PlotItem
 	LineEvents []LineEvents
+	TextItems  []TextItem
+
+	// the problems that were skipped: lines that couldn't be read,
+	// things that aren't supported and, in Lenient mode, data that
+	// couldn't be used
+	Diagnostics ParseErrors
+
+	// the options it was parsed with, and any from DrawTimeline
+	opts options
 }
 
 // Config holds the configuration variables
 type Config struct {
 	ImageSize        ImageSize
+	PlotArea         PlotArea
+	TimeAxis         TimeAxis
+	AlignBars        string // "early", "late" or "justify"
+	BackgroundColors BackgroundColors
 	Period           Period
 	ScaleMajor       Scale
 	ScaleMinor       Scale
 	DateFormat       string
-	LegendColumns    int
+	Legend           Legend
 	DefaultLineWidth int
 	MaxLineWidth     int
 	PlotTextColor    string
-	PlotTextSize     int
-	// Align            string // we are ignoring this for now
-	// Shift            string // we are ignoring this for now
+	PlotTextSize     FontSize
 }
 
 // Defaults holds defaults that aren't in the config
@@ -40,6 +51,8 @@ type Defaults struct {
 	LabelBarGap     int // the size of the gap between the label and the start of the bar
 	FontFace        string
 	FontSize        int
+	FontSizes       map[string]int // points for the FontSize keywords; DefaultFontSizes for any that aren't here
+	LabelFontSize   int            // points for the bar labels and the legend; 0 for the default, see labelFontSize
 	FontLeading     int
 	Margin          float64
 	BorderColor     string
@@ -47,11 +60,24 @@ type Defaults struct {
 	GraphicsContext *draw2dimg.GraphicContext
 }
 
+// FontSize is a size in points or one of the keywords XS, S, M, L
+// and XL, which are turned into points with Defaults.FontSizes
+type FontSize struct {
+	Points  int
+	Keyword string
+}
+
 // Derived holds computed or created parts of the timeline
 type Derived struct {
-	BarLeft        float64
-	MaxLabelWidth  float64
+	MaxLabelWidth float64
+	// the length of the time axis, which is the width of the plot area
+	// or, for vertical timelines, its height
 	TotalBarPixels float64
+	// the edges of the plot area, in pixels from the top left of the image
+	PlotLeft   float64
+	PlotRight  float64
+	PlotTop    float64
+	PlotBottom float64
 }
 
 // ImageSize stores the size of the image as specified in the file
@@ -61,44 +87,173 @@ type ImageSize struct {
 	BarincrementPx float64
 }
 
+// PlotArea stores the margins around the chart as specified in the
+// file; either right or width and either top or height are used
+type PlotArea struct {
+	Defined bool
+	Left    Dimension
+	Right   Dimension
+	Top     Dimension
+	Bottom  Dimension
+	Width   Dimension
+	Height  Dimension
+}
+
+// Dimension is a size in pixels or, if Percent is set, a percentage
+// of the size of the image
+type Dimension struct {
+	Defined bool
+	Value   float64
+	Percent bool
+}
+
+// TimeAxis stores which way time runs and how its labels are written
+type TimeAxis struct {
+	Orientation string // "horizontal" or "vertical"
+	Format      string // e.g. "yyyy"
+	Order       string // "normal" or "reverse"
+}
+
+// BackgroundColors stores the IDs (from Colors) of the colors behind
+// the chart; an empty ID means the default
+type BackgroundColors struct {
+	Canvas string // the whole image
+	Bars   string // the empty part of each bar
+	Back   string // the plot area
+}
+
+// Legend stores where the legend goes and how it is arranged; Left and
+// Top, if they are defined, put the top left corner of the legend at
+// that distance from the left and the bottom of the image
+type Legend struct {
+	Orientation string // "vertical" or "horizontal"
+	Position    string // "bottom", "top" or "right"
+	Columns     int
+	ColumnWidth Dimension
+	Left        Dimension
+	Top         Dimension
+}
+
 type Period struct {
 	From  string
 	To    string
-	Start time.Time
-	End   time.Time
+	Start Date
+	End   Date
 }
 
 // Scale holds the scale configuration
 type Scale struct {
 	Increment int
-	Start     int
+	Unit      string // "year", "month" or "day"; ignored for numeric dates
+	Start     Date   // the first tic, or zero to start with the Period
+	Format    string // for the tic labels, e.g. "mmm yyyy"; empty for the default
+	GridColor string // an ID from Colors or a color; empty for no gridlines
 }
 
 // Color stores the ID, actual value, and legend text for a color definition.
 type Color struct {
 	ID     string
-	Value  string
+	Value  string     // as written in the file, e.g. "gray(0.3)"
+	RGBA   color.RGBA // Value, checked when the file is parsed
 	Legend string
 }
 
 // Bar stores the ID and the display text for a member/bar in the timeline.
 type Bar struct {
-	ID   string
-	Text string
+	ID     string
+	Text   string
+	Link   string // a URL, from link:, or a wiki page, from a wiki link in the text
+	Barset bool   // the ID is a barset, whose items each go on a row of their own
 }
 
-// PlotItem represents an interval (e.g., a member's tenure in a role).
+// PlotItem represents an interval (e.g., a member's tenure in a role)
+// or, if Point is set, a single event (e.g., a birth or one gig).
 type PlotItem struct {
-	BarID   string
-	From    time.Time
-	Til     time.Time
-	ColorID string
-	Width   int // Corresponds to the layer width (e.g., 11, 7, 3)
-	Text    string
+	BarID string
+	From  Date
+	Til   Date
+	At    Date // only for points
+	// BarsetRow is the row an item in a barset is on, counting from 0
+	// for the row with the barset's label
+	BarsetRow int
+	Point     bool
+	ColorID   string
+	Width     int // Corresponds to the layer width (e.g., 11, 7, 3)
+	Text      string
+	Link      string // from link: or the first wiki link in the text
+	Mark      Mark
+	// Align and Anchor place Text: Anchor is the point along the bar
+	// ("from", "middle" or "till") and Align is which side of the
+	// text is at that point ("left", "center" or "right"); an empty
+	// Anchor is the middle and an empty Align is the default for the
+	// orientation
+	Align  string
+	Anchor string
+	// ShiftX and ShiftY move Text by that many pixels, up for a
+	// positive ShiftY
+	ShiftX float64
+	ShiftY float64
+	// FontSize and TextColor are for this item's Text; zero means
+	// the PlotData defaults in Config
+	FontSize  FontSize
+	TextColor string
+}
+
+// Mark is a marker drawn across a bar at a point, from mark:(line,color)
+type Mark struct {
+	Style   string // "line", or empty for no mark
+	ColorID string // an ID from Colors or a color name
 }
 
-// LineEvents represents a vertical line marker (e.g., an album release).
+// LineEvents represents a line from LineData. Usually it is a line
+// across the plot area at a date (e.g., an album release), but it can
+// also run along the time axis or join any two points.
 type LineEvents struct {
+	Kind    string // "at", "fromtill" or "points"
 	ColorID string
-	Date    time.Time
+	Width   float64
+	Layer   string // "front" (over the bars) or "back" (behind them)
+	// Date is from at:; the line runs from FromPos to TillPos, which
+	// are measured from the bottom (or, for a vertical timeline, the
+	// left) of the image, or across the whole plot area without them
+	Date    Date
+	FromPos Dimension
+	TillPos Dimension
+	// From and Till are the ends of a line along the time axis at
+	// AtPos, which is measured like FromPos and TillPos
+	From  Date
+	Till  Date
+	AtPos Dimension
+	// Points are the ends of a line in pixels from the bottom left of
+	// the image
+	Points []Point
+}
+
+// Point is a position in pixels
+type Point struct {
+	X float64
+	Y float64
+}
+
+// TextItem is a block of free text from TextData, like a caption or
+// a note about the sources.
+type TextItem struct {
+	// X and Y are from pos:, in pixels from the bottom left of the image
+	X float64
+	Y float64
+	// Line is the number of lines this text is below pos:, for text
+	// that continues an earlier item without a pos: of its own
+	Line      int
+	Text      string   // "~" starts a new line and "^" moves to the next tab stop
+	Link      string   // the page the first wiki link in the text goes to
+	FontSize  FontSize // zero for the default size
+	TextColor string
+	Tabs      []TabStop
+}
+
+// TabStop is a position, in pixels from the start of a TextItem, and
+// how the text after a "^" lines up with it ("left", "center" or "right")
+type TabStop struct {
+	Pos   float64
+	Align string
 }
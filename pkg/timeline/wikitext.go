@@ -0,0 +1,82 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Block is the text of one timeline found in wikitext
+type Block struct {
+	Source string // the EasyTimeline text, without the tags around it
+	Line   int    // the line of the wikitext that Source starts on
+}
+
+// Regex for a <timeline> tag and its contents (e.g.,
+// `<timeline height="300">...</timeline>`)
+var timelineTagRe = regexp.MustCompile(`(?is)<timeline(?:\s[^>]*)?>(.*?)</timeline\s*>`)
+
+// Regex for the start of a timeline written as a parser function
+// (e.g., `{{#tag:timeline|`)
+var tagFunctionRe = regexp.MustCompile(`(?i)\{\{\s*#tag:\s*timeline\s*\|`)
+
+// FindTimelines finds every timeline in some wikitext, like a whole
+// article, in the order they appear; they can be <timeline> tags or
+// {{#tag:timeline|...}}
+func FindTimelines(wikitext string) []Block {
+	type match struct{ start, end int }
+	var matches []match
+	for _, m := range timelineTagRe.FindAllStringSubmatchIndex(wikitext, -1) {
+		matches = append(matches, match{m[2], m[3]})
+	}
+	for offset := 0; ; {
+		m := tagFunctionRe.FindStringIndex(wikitext[offset:])
+		if m == nil {
+			break
+		}
+		start := offset + m[0]
+		end := matchingBraces(wikitext, start)
+		if end < 0 {
+			break
+		}
+		matches = append(matches, match{offset + m[1], end})
+		offset = end + 2
+	}
+	slices.SortFunc(matches, func(a, b match) int { return a.start - b.start })
+
+	var blocks []Block
+	for _, m := range matches {
+		blocks = append(blocks, Block{
+			Source: wikitext[m.start:m.end],
+			Line:   strings.Count(wikitext[:m.start], "\n") + 1,
+		})
+	}
+	return blocks
+}
+
+// ParseTimelines parses every timeline in some wikitext; text with no
+// timeline tags in it is taken to be a single EasyTimeline file. The
+// error is a ParseErrors with the problems in all of them, and a
+// timeline that has problems is nil.
+func ParseTimelines(ctx context.Context, wikitext string, opts ...Option) ([]*Timeline, error) {
+	blocks := FindTimelines(wikitext)
+	if len(blocks) == 0 {
+		blocks = []Block{{Source: wikitext, Line: 1}}
+	}
+	var timelines []*Timeline
+	var errs ParseErrors
+	for _, b := range blocks {
+		t, err := ParseTimeline(ctx, b.Source, append(slices.Clip(opts), WithStartLine(b.Line))...)
+		var blockErrs ParseErrors
+		if errors.As(err, &blockErrs) {
+			errs = append(errs, blockErrs...)
+		}
+		timelines = append(timelines, t)
+	}
+	if len(errs) > 0 {
+		return timelines, errs
+	}
+	return timelines, nil
+}
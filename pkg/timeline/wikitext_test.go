@@ -0,0 +1,65 @@
+package timeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFindTimelines(t *testing.T) {
+	wikitext := `== Members ==
+Some text with a {{cite web|url=x}} in it.
+<Timeline height="300">
+ImageSize = width:800
+</timeline>
+
+{{#tag:timeline|
+DateFormat = yyyy
+Period = from:2000 till:{{#time:Y}}
+}}
+`
+	blocks := FindTimelines(wikitext)
+	if len(blocks) != 2 {
+		t.Fatalf("expected 2 timelines, got %d: %+v", len(blocks), blocks)
+	}
+	if blocks[0].Source != "\nImageSize = width:800\n" || blocks[0].Line != 3 {
+		t.Errorf("first timeline is %+v", blocks[0])
+	}
+	if blocks[1].Source != "\nDateFormat = yyyy\nPeriod = from:2000 till:{{#time:Y}}\n" || blocks[1].Line != 7 {
+		t.Errorf("second timeline is %+v", blocks[1])
+	}
+	if blocks := FindTimelines("ImageSize = width:800\n"); len(blocks) != 0 {
+		t.Errorf("expected no timelines in a data file, got %+v", blocks)
+	}
+}
+
+func TestParseTimelines(t *testing.T) {
+	wikitext := `Intro
+<timeline>
+ImageSize = width:800
+</timeline>
+<timeline>
+ImageSize = width:wide
+</timeline>
+`
+	timelines, err := ParseTimelines(context.Background(), wikitext, WithFileName("article.wiki"))
+	if len(timelines) != 2 || timelines[0] == nil || timelines[1] != nil {
+		t.Fatalf("timelines are %+v", timelines)
+	}
+	if timelines[0].Config.ImageSize.WidthPx != 800 {
+		t.Errorf("first timeline is %+v", timelines[0].Config.ImageSize)
+	}
+	var errs ParseErrors
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %v", err)
+	}
+	// the line in the whole article, not in the timeline
+	if errs[0].Line != 6 || errs[0].File != "article.wiki" {
+		t.Errorf("error is %+v", errs[0])
+	}
+
+	timelines, err = ParseTimelines(context.Background(), "ImageSize = width:640\n")
+	if err != nil || len(timelines) != 1 || timelines[0].Config.ImageSize.WidthPx != 640 {
+		t.Errorf("a plain data file gave %+v, %v", timelines, err)
+	}
+}